@@ -39,13 +39,21 @@ const (
 	// CollectorManagerGetCollectorProcedure is the fully-qualified name of the CollectorManager's
 	// GetCollector RPC.
 	CollectorManagerGetCollectorProcedure = "/server.v1.CollectorManager/GetCollector"
+	// CollectorManagerSetCollectorOverrideProcedure is the fully-qualified name of the
+	// CollectorManager's SetCollectorOverride RPC.
+	CollectorManagerSetCollectorOverrideProcedure = "/server.v1.CollectorManager/SetCollectorOverride"
+	// CollectorManagerClearCollectorOverrideProcedure is the fully-qualified name of the
+	// CollectorManager's ClearCollectorOverride RPC.
+	CollectorManagerClearCollectorOverrideProcedure = "/server.v1.CollectorManager/ClearCollectorOverride"
 )
 
 // These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
 var (
-	collectorManagerServiceDescriptor              = v1.File_server_v1_collector_proto.Services().ByName("CollectorManager")
-	collectorManagerListCollectorsMethodDescriptor = collectorManagerServiceDescriptor.Methods().ByName("ListCollectors")
-	collectorManagerGetCollectorMethodDescriptor   = collectorManagerServiceDescriptor.Methods().ByName("GetCollector")
+	collectorManagerServiceDescriptor                      = v1.File_server_v1_collector_proto.Services().ByName("CollectorManager")
+	collectorManagerListCollectorsMethodDescriptor         = collectorManagerServiceDescriptor.Methods().ByName("ListCollectors")
+	collectorManagerGetCollectorMethodDescriptor           = collectorManagerServiceDescriptor.Methods().ByName("GetCollector")
+	collectorManagerSetCollectorOverrideMethodDescriptor   = collectorManagerServiceDescriptor.Methods().ByName("SetCollectorOverride")
+	collectorManagerClearCollectorOverrideMethodDescriptor = collectorManagerServiceDescriptor.Methods().ByName("ClearCollectorOverride")
 )
 
 // CollectorManagerClient is a client for the server.v1.CollectorManager service.
@@ -54,6 +62,10 @@ type CollectorManagerClient interface {
 	ListCollectors(context.Context, *connect.Request[v1.ListRequest]) (*connect.ServerStreamForClient[v1.GetCollectorsResponse], error)
 	// GetConfig returns the collector's configuration.
 	GetCollector(context.Context, *connect.Request[v1.GetCollectorRequest]) (*connect.Response[v1.GetCollectorsResponse], error)
+	// SetCollectorOverride attaches an override source to a collector.
+	SetCollectorOverride(context.Context, *connect.Request[v1.SetCollectorOverrideRequest]) (*connect.Response[v1.GetCollectorsResponse], error)
+	// ClearCollectorOverride removes the override of a collector.
+	ClearCollectorOverride(context.Context, *connect.Request[v1.GetCollectorRequest]) (*connect.Response[v1.GetCollectorsResponse], error)
 }
 
 // NewCollectorManagerClient constructs a client for the server.v1.CollectorManager service. By
@@ -80,13 +92,27 @@ func NewCollectorManagerClient(httpClient connect.HTTPClient, baseURL string, op
 			connect.WithIdempotency(connect.IdempotencyNoSideEffects),
 			connect.WithClientOptions(opts...),
 		),
+		setCollectorOverride: connect.NewClient[v1.SetCollectorOverrideRequest, v1.GetCollectorsResponse](
+			httpClient,
+			baseURL+CollectorManagerSetCollectorOverrideProcedure,
+			connect.WithSchema(collectorManagerSetCollectorOverrideMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		clearCollectorOverride: connect.NewClient[v1.GetCollectorRequest, v1.GetCollectorsResponse](
+			httpClient,
+			baseURL+CollectorManagerClearCollectorOverrideProcedure,
+			connect.WithSchema(collectorManagerClearCollectorOverrideMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
 // collectorManagerClient implements CollectorManagerClient.
 type collectorManagerClient struct {
-	listCollectors *connect.Client[v1.ListRequest, v1.GetCollectorsResponse]
-	getCollector   *connect.Client[v1.GetCollectorRequest, v1.GetCollectorsResponse]
+	listCollectors         *connect.Client[v1.ListRequest, v1.GetCollectorsResponse]
+	getCollector           *connect.Client[v1.GetCollectorRequest, v1.GetCollectorsResponse]
+	setCollectorOverride   *connect.Client[v1.SetCollectorOverrideRequest, v1.GetCollectorsResponse]
+	clearCollectorOverride *connect.Client[v1.GetCollectorRequest, v1.GetCollectorsResponse]
 }
 
 // ListCollectors calls server.v1.CollectorManager.ListCollectors.
@@ -99,12 +125,26 @@ func (c *collectorManagerClient) GetCollector(ctx context.Context, req *connect.
 	return c.getCollector.CallUnary(ctx, req)
 }
 
+// SetCollectorOverride calls server.v1.CollectorManager.SetCollectorOverride.
+func (c *collectorManagerClient) SetCollectorOverride(ctx context.Context, req *connect.Request[v1.SetCollectorOverrideRequest]) (*connect.Response[v1.GetCollectorsResponse], error) {
+	return c.setCollectorOverride.CallUnary(ctx, req)
+}
+
+// ClearCollectorOverride calls server.v1.CollectorManager.ClearCollectorOverride.
+func (c *collectorManagerClient) ClearCollectorOverride(ctx context.Context, req *connect.Request[v1.GetCollectorRequest]) (*connect.Response[v1.GetCollectorsResponse], error) {
+	return c.clearCollectorOverride.CallUnary(ctx, req)
+}
+
 // CollectorManagerHandler is an implementation of the server.v1.CollectorManager service.
 type CollectorManagerHandler interface {
 	// GetConfig returns the collector's configuration.
 	ListCollectors(context.Context, *connect.Request[v1.ListRequest], *connect.ServerStream[v1.GetCollectorsResponse]) error
 	// GetConfig returns the collector's configuration.
 	GetCollector(context.Context, *connect.Request[v1.GetCollectorRequest]) (*connect.Response[v1.GetCollectorsResponse], error)
+	// SetCollectorOverride attaches an override source to a collector.
+	SetCollectorOverride(context.Context, *connect.Request[v1.SetCollectorOverrideRequest]) (*connect.Response[v1.GetCollectorsResponse], error)
+	// ClearCollectorOverride removes the override of a collector.
+	ClearCollectorOverride(context.Context, *connect.Request[v1.GetCollectorRequest]) (*connect.Response[v1.GetCollectorsResponse], error)
 }
 
 // NewCollectorManagerHandler builds an HTTP handler from the service implementation. It returns the
@@ -127,12 +167,28 @@ func NewCollectorManagerHandler(svc CollectorManagerHandler, opts ...connect.Han
 		connect.WithIdempotency(connect.IdempotencyNoSideEffects),
 		connect.WithHandlerOptions(opts...),
 	)
+	collectorManagerSetCollectorOverrideHandler := connect.NewUnaryHandler(
+		CollectorManagerSetCollectorOverrideProcedure,
+		svc.SetCollectorOverride,
+		connect.WithSchema(collectorManagerSetCollectorOverrideMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	collectorManagerClearCollectorOverrideHandler := connect.NewUnaryHandler(
+		CollectorManagerClearCollectorOverrideProcedure,
+		svc.ClearCollectorOverride,
+		connect.WithSchema(collectorManagerClearCollectorOverrideMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/server.v1.CollectorManager/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case CollectorManagerListCollectorsProcedure:
 			collectorManagerListCollectorsHandler.ServeHTTP(w, r)
 		case CollectorManagerGetCollectorProcedure:
 			collectorManagerGetCollectorHandler.ServeHTTP(w, r)
+		case CollectorManagerSetCollectorOverrideProcedure:
+			collectorManagerSetCollectorOverrideHandler.ServeHTTP(w, r)
+		case CollectorManagerClearCollectorOverrideProcedure:
+			collectorManagerClearCollectorOverrideHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -149,3 +205,11 @@ func (UnimplementedCollectorManagerHandler) ListCollectors(context.Context, *con
 func (UnimplementedCollectorManagerHandler) GetCollector(context.Context, *connect.Request[v1.GetCollectorRequest]) (*connect.Response[v1.GetCollectorsResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("server.v1.CollectorManager.GetCollector is not implemented"))
 }
+
+func (UnimplementedCollectorManagerHandler) SetCollectorOverride(context.Context, *connect.Request[v1.SetCollectorOverrideRequest]) (*connect.Response[v1.GetCollectorsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("server.v1.CollectorManager.SetCollectorOverride is not implemented"))
+}
+
+func (UnimplementedCollectorManagerHandler) ClearCollectorOverride(context.Context, *connect.Request[v1.GetCollectorRequest]) (*connect.Response[v1.GetCollectorsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("server.v1.CollectorManager.ClearCollectorOverride is not implemented"))
+}
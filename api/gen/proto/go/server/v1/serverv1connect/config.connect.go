@@ -36,17 +36,69 @@ const (
 	// ConfigManagerListConfigsProcedure is the fully-qualified name of the ConfigManager's ListConfigs
 	// RPC.
 	ConfigManagerListConfigsProcedure = "/server.v1.ConfigManager/ListConfigs"
+	// ConfigManagerGetConfigMappingProcedure is the fully-qualified name of the ConfigManager's
+	// GetConfigMapping RPC.
+	ConfigManagerGetConfigMappingProcedure = "/server.v1.ConfigManager/GetConfigMapping"
+	// ConfigManagerAddConfigProcedure is the fully-qualified name of the ConfigManager's AddConfig RPC.
+	ConfigManagerAddConfigProcedure = "/server.v1.ConfigManager/AddConfig"
+	// ConfigManagerUpdateConfigProcedure is the fully-qualified name of the ConfigManager's
+	// UpdateConfig RPC.
+	ConfigManagerUpdateConfigProcedure = "/server.v1.ConfigManager/UpdateConfig"
+	// ConfigManagerRemoveConfigProcedure is the fully-qualified name of the ConfigManager's
+	// RemoveConfig RPC.
+	ConfigManagerRemoveConfigProcedure = "/server.v1.ConfigManager/RemoveConfig"
+	// ConfigManagerPromoteConfigProcedure is the fully-qualified name of the ConfigManager's
+	// PromoteConfig RPC.
+	ConfigManagerPromoteConfigProcedure = "/server.v1.ConfigManager/PromoteConfig"
+	// ConfigManagerRollbackConfigProcedure is the fully-qualified name of the ConfigManager's
+	// RollbackConfig RPC.
+	ConfigManagerRollbackConfigProcedure = "/server.v1.ConfigManager/RollbackConfig"
+	// ConfigManagerListConfigVersionsProcedure is the fully-qualified name of the ConfigManager's
+	// ListConfigVersions RPC.
+	ConfigManagerListConfigVersionsProcedure = "/server.v1.ConfigManager/ListConfigVersions"
+	// ConfigManagerPinConfigProcedure is the fully-qualified name of the ConfigManager's PinConfig RPC.
+	ConfigManagerPinConfigProcedure = "/server.v1.ConfigManager/PinConfig"
+	// ConfigManagerUnpinConfigProcedure is the fully-qualified name of the ConfigManager's UnpinConfig
+	// RPC.
+	ConfigManagerUnpinConfigProcedure = "/server.v1.ConfigManager/UnpinConfig"
 )
 
 // These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
 var (
-	configManagerServiceDescriptor           = v1.File_server_v1_config_proto.Services().ByName("ConfigManager")
-	configManagerListConfigsMethodDescriptor = configManagerServiceDescriptor.Methods().ByName("ListConfigs")
+	configManagerServiceDescriptor                  = v1.File_server_v1_config_proto.Services().ByName("ConfigManager")
+	configManagerListConfigsMethodDescriptor        = configManagerServiceDescriptor.Methods().ByName("ListConfigs")
+	configManagerGetConfigMappingMethodDescriptor   = configManagerServiceDescriptor.Methods().ByName("GetConfigMapping")
+	configManagerAddConfigMethodDescriptor          = configManagerServiceDescriptor.Methods().ByName("AddConfig")
+	configManagerUpdateConfigMethodDescriptor       = configManagerServiceDescriptor.Methods().ByName("UpdateConfig")
+	configManagerRemoveConfigMethodDescriptor       = configManagerServiceDescriptor.Methods().ByName("RemoveConfig")
+	configManagerPromoteConfigMethodDescriptor      = configManagerServiceDescriptor.Methods().ByName("PromoteConfig")
+	configManagerRollbackConfigMethodDescriptor     = configManagerServiceDescriptor.Methods().ByName("RollbackConfig")
+	configManagerListConfigVersionsMethodDescriptor = configManagerServiceDescriptor.Methods().ByName("ListConfigVersions")
+	configManagerPinConfigMethodDescriptor          = configManagerServiceDescriptor.Methods().ByName("PinConfig")
+	configManagerUnpinConfigMethodDescriptor        = configManagerServiceDescriptor.Methods().ByName("UnpinConfig")
 )
 
 // ConfigManagerClient is a client for the server.v1.ConfigManager service.
 type ConfigManagerClient interface {
 	ListConfigs(context.Context, *connect.Request[v1.ListRequest]) (*connect.ServerStreamForClient[v1.GetConfigResponse], error)
+	// GetConfigMapping returns the mapping of a source
+	GetConfigMapping(context.Context, *connect.Request[v1.ConfigSourceRequest]) (*connect.Response[v1.GetConfigResponse], error)
+	// AddConfig adds a new mapping, fails if the source is already mapped
+	AddConfig(context.Context, *connect.Request[v1.ConfigMappingRequest]) (*connect.Response[v1.GetConfigResponse], error)
+	// UpdateConfig replaces the mapping of an already mapped source
+	UpdateConfig(context.Context, *connect.Request[v1.ConfigMappingRequest]) (*connect.Response[v1.GetConfigResponse], error)
+	// RemoveConfig removes the mapping of a source
+	RemoveConfig(context.Context, *connect.Request[v1.ConfigSourceRequest]) (*connect.Response[v1.RemoveConfigResponse], error)
+	// PromoteConfig delivers the content under rollout to all collectors
+	PromoteConfig(context.Context, *connect.Request[v1.ConfigSourceRequest]) (*connect.Response[v1.GetConfigResponse], error)
+	// RollbackConfig stops the rollout, collectors keep the stable content until the source changes again
+	RollbackConfig(context.Context, *connect.Request[v1.ConfigSourceRequest]) (*connect.Response[v1.GetConfigResponse], error)
+	// ListConfigVersions returns the content history of a mapping
+	ListConfigVersions(context.Context, *connect.Request[v1.ConfigSourceRequest]) (*connect.Response[v1.ListConfigVersionsResponse], error)
+	// PinConfig delivers a version of the history instead of the source to all collectors
+	PinConfig(context.Context, *connect.Request[v1.PinConfigRequest]) (*connect.Response[v1.GetConfigResponse], error)
+	// UnpinConfig delivers the source again
+	UnpinConfig(context.Context, *connect.Request[v1.ConfigSourceRequest]) (*connect.Response[v1.GetConfigResponse], error)
 }
 
 // NewConfigManagerClient constructs a client for the server.v1.ConfigManager service. By default,
@@ -66,12 +118,81 @@ func NewConfigManagerClient(httpClient connect.HTTPClient, baseURL string, opts
 			connect.WithIdempotency(connect.IdempotencyNoSideEffects),
 			connect.WithClientOptions(opts...),
 		),
+		getConfigMapping: connect.NewClient[v1.ConfigSourceRequest, v1.GetConfigResponse](
+			httpClient,
+			baseURL+ConfigManagerGetConfigMappingProcedure,
+			connect.WithSchema(configManagerGetConfigMappingMethodDescriptor),
+			connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+			connect.WithClientOptions(opts...),
+		),
+		addConfig: connect.NewClient[v1.ConfigMappingRequest, v1.GetConfigResponse](
+			httpClient,
+			baseURL+ConfigManagerAddConfigProcedure,
+			connect.WithSchema(configManagerAddConfigMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		updateConfig: connect.NewClient[v1.ConfigMappingRequest, v1.GetConfigResponse](
+			httpClient,
+			baseURL+ConfigManagerUpdateConfigProcedure,
+			connect.WithSchema(configManagerUpdateConfigMethodDescriptor),
+			connect.WithIdempotency(connect.IdempotencyIdempotent),
+			connect.WithClientOptions(opts...),
+		),
+		removeConfig: connect.NewClient[v1.ConfigSourceRequest, v1.RemoveConfigResponse](
+			httpClient,
+			baseURL+ConfigManagerRemoveConfigProcedure,
+			connect.WithSchema(configManagerRemoveConfigMethodDescriptor),
+			connect.WithIdempotency(connect.IdempotencyIdempotent),
+			connect.WithClientOptions(opts...),
+		),
+		promoteConfig: connect.NewClient[v1.ConfigSourceRequest, v1.GetConfigResponse](
+			httpClient,
+			baseURL+ConfigManagerPromoteConfigProcedure,
+			connect.WithSchema(configManagerPromoteConfigMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		rollbackConfig: connect.NewClient[v1.ConfigSourceRequest, v1.GetConfigResponse](
+			httpClient,
+			baseURL+ConfigManagerRollbackConfigProcedure,
+			connect.WithSchema(configManagerRollbackConfigMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		listConfigVersions: connect.NewClient[v1.ConfigSourceRequest, v1.ListConfigVersionsResponse](
+			httpClient,
+			baseURL+ConfigManagerListConfigVersionsProcedure,
+			connect.WithSchema(configManagerListConfigVersionsMethodDescriptor),
+			connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+			connect.WithClientOptions(opts...),
+		),
+		pinConfig: connect.NewClient[v1.PinConfigRequest, v1.GetConfigResponse](
+			httpClient,
+			baseURL+ConfigManagerPinConfigProcedure,
+			connect.WithSchema(configManagerPinConfigMethodDescriptor),
+			connect.WithIdempotency(connect.IdempotencyIdempotent),
+			connect.WithClientOptions(opts...),
+		),
+		unpinConfig: connect.NewClient[v1.ConfigSourceRequest, v1.GetConfigResponse](
+			httpClient,
+			baseURL+ConfigManagerUnpinConfigProcedure,
+			connect.WithSchema(configManagerUnpinConfigMethodDescriptor),
+			connect.WithIdempotency(connect.IdempotencyIdempotent),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
 // configManagerClient implements ConfigManagerClient.
 type configManagerClient struct {
-	listConfigs *connect.Client[v1.ListRequest, v1.GetConfigResponse]
+	listConfigs        *connect.Client[v1.ListRequest, v1.GetConfigResponse]
+	getConfigMapping   *connect.Client[v1.ConfigSourceRequest, v1.GetConfigResponse]
+	addConfig          *connect.Client[v1.ConfigMappingRequest, v1.GetConfigResponse]
+	updateConfig       *connect.Client[v1.ConfigMappingRequest, v1.GetConfigResponse]
+	removeConfig       *connect.Client[v1.ConfigSourceRequest, v1.RemoveConfigResponse]
+	promoteConfig      *connect.Client[v1.ConfigSourceRequest, v1.GetConfigResponse]
+	rollbackConfig     *connect.Client[v1.ConfigSourceRequest, v1.GetConfigResponse]
+	listConfigVersions *connect.Client[v1.ConfigSourceRequest, v1.ListConfigVersionsResponse]
+	pinConfig          *connect.Client[v1.PinConfigRequest, v1.GetConfigResponse]
+	unpinConfig        *connect.Client[v1.ConfigSourceRequest, v1.GetConfigResponse]
 }
 
 // ListConfigs calls server.v1.ConfigManager.ListConfigs.
@@ -79,9 +200,72 @@ func (c *configManagerClient) ListConfigs(ctx context.Context, req *connect.Requ
 	return c.listConfigs.CallServerStream(ctx, req)
 }
 
+// GetConfigMapping calls server.v1.ConfigManager.GetConfigMapping.
+func (c *configManagerClient) GetConfigMapping(ctx context.Context, req *connect.Request[v1.ConfigSourceRequest]) (*connect.Response[v1.GetConfigResponse], error) {
+	return c.getConfigMapping.CallUnary(ctx, req)
+}
+
+// AddConfig calls server.v1.ConfigManager.AddConfig.
+func (c *configManagerClient) AddConfig(ctx context.Context, req *connect.Request[v1.ConfigMappingRequest]) (*connect.Response[v1.GetConfigResponse], error) {
+	return c.addConfig.CallUnary(ctx, req)
+}
+
+// UpdateConfig calls server.v1.ConfigManager.UpdateConfig.
+func (c *configManagerClient) UpdateConfig(ctx context.Context, req *connect.Request[v1.ConfigMappingRequest]) (*connect.Response[v1.GetConfigResponse], error) {
+	return c.updateConfig.CallUnary(ctx, req)
+}
+
+// RemoveConfig calls server.v1.ConfigManager.RemoveConfig.
+func (c *configManagerClient) RemoveConfig(ctx context.Context, req *connect.Request[v1.ConfigSourceRequest]) (*connect.Response[v1.RemoveConfigResponse], error) {
+	return c.removeConfig.CallUnary(ctx, req)
+}
+
+// PromoteConfig calls server.v1.ConfigManager.PromoteConfig.
+func (c *configManagerClient) PromoteConfig(ctx context.Context, req *connect.Request[v1.ConfigSourceRequest]) (*connect.Response[v1.GetConfigResponse], error) {
+	return c.promoteConfig.CallUnary(ctx, req)
+}
+
+// RollbackConfig calls server.v1.ConfigManager.RollbackConfig.
+func (c *configManagerClient) RollbackConfig(ctx context.Context, req *connect.Request[v1.ConfigSourceRequest]) (*connect.Response[v1.GetConfigResponse], error) {
+	return c.rollbackConfig.CallUnary(ctx, req)
+}
+
+// ListConfigVersions calls server.v1.ConfigManager.ListConfigVersions.
+func (c *configManagerClient) ListConfigVersions(ctx context.Context, req *connect.Request[v1.ConfigSourceRequest]) (*connect.Response[v1.ListConfigVersionsResponse], error) {
+	return c.listConfigVersions.CallUnary(ctx, req)
+}
+
+// PinConfig calls server.v1.ConfigManager.PinConfig.
+func (c *configManagerClient) PinConfig(ctx context.Context, req *connect.Request[v1.PinConfigRequest]) (*connect.Response[v1.GetConfigResponse], error) {
+	return c.pinConfig.CallUnary(ctx, req)
+}
+
+// UnpinConfig calls server.v1.ConfigManager.UnpinConfig.
+func (c *configManagerClient) UnpinConfig(ctx context.Context, req *connect.Request[v1.ConfigSourceRequest]) (*connect.Response[v1.GetConfigResponse], error) {
+	return c.unpinConfig.CallUnary(ctx, req)
+}
+
 // ConfigManagerHandler is an implementation of the server.v1.ConfigManager service.
 type ConfigManagerHandler interface {
 	ListConfigs(context.Context, *connect.Request[v1.ListRequest], *connect.ServerStream[v1.GetConfigResponse]) error
+	// GetConfigMapping returns the mapping of a source
+	GetConfigMapping(context.Context, *connect.Request[v1.ConfigSourceRequest]) (*connect.Response[v1.GetConfigResponse], error)
+	// AddConfig adds a new mapping, fails if the source is already mapped
+	AddConfig(context.Context, *connect.Request[v1.ConfigMappingRequest]) (*connect.Response[v1.GetConfigResponse], error)
+	// UpdateConfig replaces the mapping of an already mapped source
+	UpdateConfig(context.Context, *connect.Request[v1.ConfigMappingRequest]) (*connect.Response[v1.GetConfigResponse], error)
+	// RemoveConfig removes the mapping of a source
+	RemoveConfig(context.Context, *connect.Request[v1.ConfigSourceRequest]) (*connect.Response[v1.RemoveConfigResponse], error)
+	// PromoteConfig delivers the content under rollout to all collectors
+	PromoteConfig(context.Context, *connect.Request[v1.ConfigSourceRequest]) (*connect.Response[v1.GetConfigResponse], error)
+	// RollbackConfig stops the rollout, collectors keep the stable content until the source changes again
+	RollbackConfig(context.Context, *connect.Request[v1.ConfigSourceRequest]) (*connect.Response[v1.GetConfigResponse], error)
+	// ListConfigVersions returns the content history of a mapping
+	ListConfigVersions(context.Context, *connect.Request[v1.ConfigSourceRequest]) (*connect.Response[v1.ListConfigVersionsResponse], error)
+	// PinConfig delivers a version of the history instead of the source to all collectors
+	PinConfig(context.Context, *connect.Request[v1.PinConfigRequest]) (*connect.Response[v1.GetConfigResponse], error)
+	// UnpinConfig delivers the source again
+	UnpinConfig(context.Context, *connect.Request[v1.ConfigSourceRequest]) (*connect.Response[v1.GetConfigResponse], error)
 }
 
 // NewConfigManagerHandler builds an HTTP handler from the service implementation. It returns the
@@ -97,10 +281,88 @@ func NewConfigManagerHandler(svc ConfigManagerHandler, opts ...connect.HandlerOp
 		connect.WithIdempotency(connect.IdempotencyNoSideEffects),
 		connect.WithHandlerOptions(opts...),
 	)
+	configManagerGetConfigMappingHandler := connect.NewUnaryHandler(
+		ConfigManagerGetConfigMappingProcedure,
+		svc.GetConfigMapping,
+		connect.WithSchema(configManagerGetConfigMappingMethodDescriptor),
+		connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+		connect.WithHandlerOptions(opts...),
+	)
+	configManagerAddConfigHandler := connect.NewUnaryHandler(
+		ConfigManagerAddConfigProcedure,
+		svc.AddConfig,
+		connect.WithSchema(configManagerAddConfigMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	configManagerUpdateConfigHandler := connect.NewUnaryHandler(
+		ConfigManagerUpdateConfigProcedure,
+		svc.UpdateConfig,
+		connect.WithSchema(configManagerUpdateConfigMethodDescriptor),
+		connect.WithIdempotency(connect.IdempotencyIdempotent),
+		connect.WithHandlerOptions(opts...),
+	)
+	configManagerRemoveConfigHandler := connect.NewUnaryHandler(
+		ConfigManagerRemoveConfigProcedure,
+		svc.RemoveConfig,
+		connect.WithSchema(configManagerRemoveConfigMethodDescriptor),
+		connect.WithIdempotency(connect.IdempotencyIdempotent),
+		connect.WithHandlerOptions(opts...),
+	)
+	configManagerPromoteConfigHandler := connect.NewUnaryHandler(
+		ConfigManagerPromoteConfigProcedure,
+		svc.PromoteConfig,
+		connect.WithSchema(configManagerPromoteConfigMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	configManagerRollbackConfigHandler := connect.NewUnaryHandler(
+		ConfigManagerRollbackConfigProcedure,
+		svc.RollbackConfig,
+		connect.WithSchema(configManagerRollbackConfigMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	configManagerListConfigVersionsHandler := connect.NewUnaryHandler(
+		ConfigManagerListConfigVersionsProcedure,
+		svc.ListConfigVersions,
+		connect.WithSchema(configManagerListConfigVersionsMethodDescriptor),
+		connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+		connect.WithHandlerOptions(opts...),
+	)
+	configManagerPinConfigHandler := connect.NewUnaryHandler(
+		ConfigManagerPinConfigProcedure,
+		svc.PinConfig,
+		connect.WithSchema(configManagerPinConfigMethodDescriptor),
+		connect.WithIdempotency(connect.IdempotencyIdempotent),
+		connect.WithHandlerOptions(opts...),
+	)
+	configManagerUnpinConfigHandler := connect.NewUnaryHandler(
+		ConfigManagerUnpinConfigProcedure,
+		svc.UnpinConfig,
+		connect.WithSchema(configManagerUnpinConfigMethodDescriptor),
+		connect.WithIdempotency(connect.IdempotencyIdempotent),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/server.v1.ConfigManager/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case ConfigManagerListConfigsProcedure:
 			configManagerListConfigsHandler.ServeHTTP(w, r)
+		case ConfigManagerGetConfigMappingProcedure:
+			configManagerGetConfigMappingHandler.ServeHTTP(w, r)
+		case ConfigManagerAddConfigProcedure:
+			configManagerAddConfigHandler.ServeHTTP(w, r)
+		case ConfigManagerUpdateConfigProcedure:
+			configManagerUpdateConfigHandler.ServeHTTP(w, r)
+		case ConfigManagerRemoveConfigProcedure:
+			configManagerRemoveConfigHandler.ServeHTTP(w, r)
+		case ConfigManagerPromoteConfigProcedure:
+			configManagerPromoteConfigHandler.ServeHTTP(w, r)
+		case ConfigManagerRollbackConfigProcedure:
+			configManagerRollbackConfigHandler.ServeHTTP(w, r)
+		case ConfigManagerListConfigVersionsProcedure:
+			configManagerListConfigVersionsHandler.ServeHTTP(w, r)
+		case ConfigManagerPinConfigProcedure:
+			configManagerPinConfigHandler.ServeHTTP(w, r)
+		case ConfigManagerUnpinConfigProcedure:
+			configManagerUnpinConfigHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -113,3 +375,39 @@ type UnimplementedConfigManagerHandler struct{}
 func (UnimplementedConfigManagerHandler) ListConfigs(context.Context, *connect.Request[v1.ListRequest], *connect.ServerStream[v1.GetConfigResponse]) error {
 	return connect.NewError(connect.CodeUnimplemented, errors.New("server.v1.ConfigManager.ListConfigs is not implemented"))
 }
+
+func (UnimplementedConfigManagerHandler) GetConfigMapping(context.Context, *connect.Request[v1.ConfigSourceRequest]) (*connect.Response[v1.GetConfigResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("server.v1.ConfigManager.GetConfigMapping is not implemented"))
+}
+
+func (UnimplementedConfigManagerHandler) AddConfig(context.Context, *connect.Request[v1.ConfigMappingRequest]) (*connect.Response[v1.GetConfigResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("server.v1.ConfigManager.AddConfig is not implemented"))
+}
+
+func (UnimplementedConfigManagerHandler) UpdateConfig(context.Context, *connect.Request[v1.ConfigMappingRequest]) (*connect.Response[v1.GetConfigResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("server.v1.ConfigManager.UpdateConfig is not implemented"))
+}
+
+func (UnimplementedConfigManagerHandler) RemoveConfig(context.Context, *connect.Request[v1.ConfigSourceRequest]) (*connect.Response[v1.RemoveConfigResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("server.v1.ConfigManager.RemoveConfig is not implemented"))
+}
+
+func (UnimplementedConfigManagerHandler) PromoteConfig(context.Context, *connect.Request[v1.ConfigSourceRequest]) (*connect.Response[v1.GetConfigResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("server.v1.ConfigManager.PromoteConfig is not implemented"))
+}
+
+func (UnimplementedConfigManagerHandler) RollbackConfig(context.Context, *connect.Request[v1.ConfigSourceRequest]) (*connect.Response[v1.GetConfigResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("server.v1.ConfigManager.RollbackConfig is not implemented"))
+}
+
+func (UnimplementedConfigManagerHandler) ListConfigVersions(context.Context, *connect.Request[v1.ConfigSourceRequest]) (*connect.Response[v1.ListConfigVersionsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("server.v1.ConfigManager.ListConfigVersions is not implemented"))
+}
+
+func (UnimplementedConfigManagerHandler) PinConfig(context.Context, *connect.Request[v1.PinConfigRequest]) (*connect.Response[v1.GetConfigResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("server.v1.ConfigManager.PinConfig is not implemented"))
+}
+
+func (UnimplementedConfigManagerHandler) UnpinConfig(context.Context, *connect.Request[v1.ConfigSourceRequest]) (*connect.Response[v1.GetConfigResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("server.v1.ConfigManager.UnpinConfig is not implemented"))
+}
@@ -0,0 +1,6 @@
+// Package api holds the parts of the server protocol not described by the protobuf services
+package api
+
+// StaleSourcesHeader lists the sources of a config response that were served
+// from their last known good content as they failed
+const StaleSourcesHeader = "Arcs-Stale-Sources"
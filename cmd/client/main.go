@@ -1,18 +1,24 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
 
 	"connectrpc.com/connect"
 	collectorv1 "github.com/grafana/alloy-remote-config/api/gen/proto/go/collector/v1"
 	"github.com/grafana/alloy-remote-config/api/gen/proto/go/collector/v1/collectorv1connect"
+	"github.com/myLogic207/go-arcs/api"
 	serverv1 "github.com/myLogic207/go-arcs/api/gen/proto/go/server/v1"
 	"github.com/myLogic207/go-arcs/api/gen/proto/go/server/v1/serverv1connect"
 	"github.com/myLogic207/go-arcs/internal/args"
+	"github.com/myLogic207/go-arcs/pkg/auth"
 )
 
 var (
@@ -27,27 +33,59 @@ var (
 			Value:   "172.17.0.1",
 			Message: "The IP Address to bind to, if none specified uses default docker host address",
 		},
-		// args.Flag{
-		// 	Name:    "validate",
-		// 	Value:   false,
-		// 	Message: "Flag to validate the configuration and the stop",
-		// },
+		"ca": {
+			Name:    "ca",
+			Value:   "",
+			Message: "Path to PEM certificate authorities to verify the server with, enables TLS",
+		},
+		"cert": {
+			Name:    "cert",
+			Value:   "",
+			Message: "Path to a PEM client certificate for mutual TLS, enables TLS",
+		},
+		"key": {
+			Name:    "key",
+			Value:   "",
+			Message: "Path to the PEM private key of -cert",
+		},
+		"tls": {
+			Name:    "tls",
+			Value:   false,
+			Message: "Connect with TLS, verifying the server with the system certificate authorities",
+		},
+		"token": {
+			Name:    "token",
+			Value:   "",
+			Message: "Bearer token for the management services, defaults to $" + tokenEnv,
+		},
 	}
 )
 
-const ID = "ARCS-Client"
+const (
+	ID       = "ARCS-Client"
+	tokenEnv = "ARCS_TOKEN"
+)
 
 type action byte
 
 const (
-	listConfigs = action(0x11)
-	getConfig   = action(0x12)
-	// addConfig    = action(0x13)
-	// removeConfig    = action(0x14)
-	listCollectors  = action(0x21)
-	getCollector    = action(0x22)
-	addCollector    = action(0x23)
-	removeCollector = action(0x24)
+	listConfigs       = action(0x11)
+	getConfig         = action(0x12)
+	addConfig         = action(0x13)
+	removeConfig      = action(0x14)
+	updateConfig      = action(0x15)
+	configMapping     = action(0x16)
+	promoteConfig     = action(0x17)
+	rollbackConfig    = action(0x18)
+	configVersions    = action(0x19)
+	pinConfig         = action(0x1a)
+	unpinConfig       = action(0x1b)
+	listCollectors    = action(0x21)
+	getCollector      = action(0x22)
+	addCollector      = action(0x23)
+	removeCollector   = action(0x24)
+	overrideCollector = action(0x2c)
+	resetCollector    = action(0x2d)
 )
 
 func parseArgs(names []string) (action, []string) {
@@ -75,6 +113,24 @@ func parseArgs(names []string) (action, []string) {
 		raw += 0x03
 	case "remove":
 		raw += 0x04
+	case "update":
+		raw += 0x05
+	case "mapping":
+		raw += 0x06
+	case "promote":
+		raw += 0x07
+	case "rollback":
+		raw += 0x08
+	case "versions":
+		raw += 0x09
+	case "pin":
+		raw += 0x0a
+	case "unpin":
+		raw += 0x0b
+	case "override":
+		raw += 0x0c
+	case "reset":
+		raw += 0x0d
 	}
 	if raw <= 0x10 {
 		log.Fatalf("No known action '%v' for '%v", names[1], names[0])
@@ -98,6 +154,144 @@ func parseAttributes(raw string) (map[string]string, error) {
 	return attributes, nil
 }
 
+const mappingUsage = "Usage: config add|update [source] [attributes] [option=value ...]"
+
+// returns the rollout of a mapping, adding it if missing
+func rollout(mapping *serverv1.ConfigMappingRequest) *serverv1.Rollout {
+	if mapping.Rollout == nil {
+		mapping.Rollout = &serverv1.Rollout{}
+	}
+	return mapping.Rollout
+}
+
+// config mappings take the form of source [key=value,key2=value2] [option=value ...]
+// with the options match, selector, template, priority, ttl, required, fallback
+// and rollout.percent, rollout.collector (repeatable) and rollout.soak
+func parseMapping(raw []string) (*serverv1.ConfigMappingRequest, error) {
+	if len(raw) < 1 {
+		return nil, errors.New("missing source. " + mappingUsage)
+	}
+	mapping := &serverv1.ConfigMappingRequest{
+		Source: raw[0],
+	}
+	if len(raw) >= 2 && raw[1] != "" {
+		attributes, err := parseAttributes(raw[1])
+		if err != nil {
+			return nil, err
+		}
+		mapping.LocalAttributes = attributes
+	}
+	for _, option := range raw[min(len(raw), 2):] {
+		key, value, ok := strings.Cut(option, "=")
+		if !ok {
+			return nil, fmt.Errorf("could not parse %v as option. %v", option, mappingUsage)
+		}
+		switch key {
+		case "match":
+			mapping.Match = value
+		case "selector":
+			mapping.Selector = value
+		case "template":
+			template, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse %v as template flag", value)
+			}
+			mapping.Template = template
+		case "priority":
+			priority, err := strconv.ParseInt(value, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse %v as priority", value)
+			}
+			mapping.Priority = int32(priority)
+		case "ttl":
+			mapping.Ttl = value
+		case "required":
+			required, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse %v as required flag", value)
+			}
+			mapping.Required = required
+		case "fallback":
+			mapping.Fallback = value
+		case "rollout.percent":
+			percent, err := strconv.ParseInt(value, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse %v as rollout percent", value)
+			}
+			rollout(mapping).Percent = int32(percent)
+		case "rollout.collector":
+			rollout(mapping).Collectors = append(rollout(mapping).Collectors, value)
+		case "rollout.soak":
+			rollout(mapping).Soak = value
+		default:
+			return nil, fmt.Errorf("unknown option %v. %v", key, mappingUsage)
+		}
+	}
+	return mapping, nil
+}
+
+func printConfig(config *serverv1.GetConfigResponse) {
+	log.Printf(
+		"%v\n(%v of %+v; %v; template %v; priority %v; ttl %v; required %v; fallback %v)",
+		config.GetSource(),
+		config.GetMatch(),
+		config.GetLocalAttributes(),
+		config.GetSelector(),
+		config.GetTemplate(),
+		config.GetPriority(),
+		config.GetTtl(),
+		config.GetRequired(),
+		config.GetFallback(),
+	)
+	if rollout := config.GetRollout(); rollout != nil {
+		status := config.GetRolloutStatus()
+		log.Printf(
+			"rollout to %v%% and %v, soak %v: active %v, stable %v, canary %v, rolled back %v",
+			rollout.GetPercent(),
+			rollout.GetCollectors(),
+			rollout.GetSoak(),
+			status.GetActive(),
+			status.GetStableHash(),
+			status.GetCanaryHash(),
+			status.GetRolledBackHash(),
+		)
+		if held := status.GetHeld(); held != "" {
+			log.Printf("rollout held: %v", held)
+		}
+	}
+	if pinned := config.GetPinnedHash(); pinned != "" {
+		log.Printf("pinned to version %v", pinned)
+	}
+	if parseErr := config.GetParseError(); parseErr != "" {
+		log.Printf("latest content failed to parse:\n%v", parseErr)
+	}
+}
+
+func printCollector(collector *serverv1.GetCollectorsResponse) {
+	lastSeen := "never"
+	if collector.GetLastSeen() != nil {
+		lastSeen = collector.GetLastSeen().AsTime().Local().String()
+	}
+	log.Printf(
+		"%v: %v\n(%+v; %v, last seen %v from %v; hash %v; last error %q)",
+		collector.GetId(),
+		collector.GetName(),
+		collector.GetLocalAttributes(),
+		collector.GetHealth(),
+		lastSeen,
+		collector.GetPeer(),
+		collector.GetHash(),
+		collector.GetLastError(),
+	)
+	if override := collector.GetOverride(); override != nil {
+		expires := "never"
+		if override.GetExpires() != nil {
+			expires = override.GetExpires().AsTime().Local().String()
+		}
+		log.Printf("override %v (%v, expires %v)", override.GetSource(), override.GetMode(), expires)
+	}
+}
+
 func registerClient(ctx context.Context, client collectorv1connect.CollectorServiceClient) error {
 	_, err := client.RegisterCollector(ctx, connect.NewRequest(&collectorv1.RegisterCollectorRequest{
 		Id:              ID,
@@ -117,18 +311,41 @@ func unregisterClient(ctx context.Context, client collectorv1connect.CollectorSe
 func main() {
 	ctx := context.Background()
 	flags, unnamed := args.Init(customFlags)
-	address := fmt.Sprintf("http://%v:%v", *flags["addr"].(*string), *flags["port"].(*int))
+	scheme := "http"
+	client := http.DefaultClient
+	ca, cert, key := *flags["ca"].(*string), *flags["cert"].(*string), *flags["key"].(*string)
+	if *flags["tls"].(*bool) || ca != "" || cert != "" {
+		tlsConfig, err := auth.ClientTLSConfig(ca, cert, key)
+		if err != nil {
+			log.Fatal(err)
+		}
+		scheme = "https"
+		client = &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig:   tlsConfig,
+				ForceAttemptHTTP2: true,
+			},
+		}
+	}
+	address := fmt.Sprintf("%v://%v:%v", scheme, *flags["addr"].(*string), *flags["port"].(*int))
+	var clientOptions []connect.ClientOption
+	if token := cmp.Or(*flags["token"].(*string), os.Getenv(tokenEnv)); token != "" {
+		clientOptions = append(clientOptions, connect.WithInterceptors(auth.NewBearerToken(token)))
+	}
 	configClient := serverv1connect.NewConfigManagerClient(
-		http.DefaultClient,
+		client,
 		address,
+		clientOptions...,
 	)
 	collectorClient := collectorv1connect.NewCollectorServiceClient(
-		http.DefaultClient,
+		client,
 		address,
+		clientOptions...,
 	)
 	collectorClientAddon := serverv1connect.NewCollectorManagerClient(
-		http.DefaultClient,
+		client,
 		address,
+		clientOptions...,
 	)
 
 	if err := registerClient(ctx, collectorClient); err != nil {
@@ -157,11 +374,130 @@ func main() {
 		if err != nil {
 			log.Fatal(err)
 		}
-		// log.Print(res.Msg().GetSource())
 		for res.Receive() {
-			log.Print(res.Msg().GetSource())
+			printConfig(res.Msg())
+		}
+	case configMapping:
+		if len(rawArguments) < 1 {
+			log.Fatal("missing source. Usage: config mapping [source]")
 		}
+		res, err := configClient.GetConfigMapping(
+			ctx,
+			connect.NewRequest(&serverv1.ConfigSourceRequest{
+				Source: rawArguments[0],
+			}),
+		)
+		if err != nil {
+			log.Fatal(err)
+		}
+		printConfig(res.Msg)
+	case addConfig:
+		mapping, err := parseMapping(rawArguments)
+		if err != nil {
+			log.Fatal(err)
+		}
+		res, err := configClient.AddConfig(ctx, connect.NewRequest(mapping))
+		if err != nil {
+			log.Fatal(err)
+		}
+		printConfig(res.Msg)
+	case updateConfig:
+		mapping, err := parseMapping(rawArguments)
+		if err != nil {
+			log.Fatal(err)
+		}
+		res, err := configClient.UpdateConfig(ctx, connect.NewRequest(mapping))
+		if err != nil {
+			log.Fatal(err)
+		}
+		printConfig(res.Msg)
+	case removeConfig:
+		if len(rawArguments) < 1 {
+			log.Fatal("missing source. Usage: config remove [source]")
+		}
+		res, err := configClient.RemoveConfig(
+			ctx,
+			connect.NewRequest(&serverv1.ConfigSourceRequest{
+				Source: rawArguments[0],
+			}),
+		)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("removed %v: %v", rawArguments[0], res.Msg.GetRemoved())
+	case promoteConfig, rollbackConfig:
+		if len(rawArguments) < 1 {
+			log.Fatal("missing source. Usage: config promote|rollback [source]")
+		}
+		end := configClient.PromoteConfig
+		if action == rollbackConfig {
+			end = configClient.RollbackConfig
+		}
+		res, err := end(
+			ctx,
+			connect.NewRequest(&serverv1.ConfigSourceRequest{
+				Source: rawArguments[0],
+			}),
+		)
+		if err != nil {
+			log.Fatal(err)
+		}
+		printConfig(res.Msg)
+	case configVersions:
+		if len(rawArguments) < 1 {
+			log.Fatal("missing source. Usage: config versions [source]")
+		}
+		res, err := configClient.ListConfigVersions(
+			ctx,
+			connect.NewRequest(&serverv1.ConfigSourceRequest{
+				Source: rawArguments[0],
+			}),
+		)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, version := range res.Msg.GetVersions() {
+			log.Printf(
+				"%v (fetched %v; revision %v; pinned %v)",
+				version.GetHash(),
+				version.GetFetched().AsTime().Local(),
+				version.GetRevision(),
+				version.GetPinned(),
+			)
+		}
+	case pinConfig:
+		if len(rawArguments) < 2 {
+			log.Fatal("missing source or hash. Usage: config pin [source] [hash]")
+		}
+		res, err := configClient.PinConfig(
+			ctx,
+			connect.NewRequest(&serverv1.PinConfigRequest{
+				Source: rawArguments[0],
+				Hash:   rawArguments[1],
+			}),
+		)
+		if err != nil {
+			log.Fatal(err)
+		}
+		printConfig(res.Msg)
+	case unpinConfig:
+		if len(rawArguments) < 1 {
+			log.Fatal("missing source. Usage: config unpin [source]")
+		}
+		res, err := configClient.UnpinConfig(
+			ctx,
+			connect.NewRequest(&serverv1.ConfigSourceRequest{
+				Source: rawArguments[0],
+			}),
+		)
+		if err != nil {
+			log.Fatal(err)
+		}
+		printConfig(res.Msg)
 	case getConfig:
+		if len(rawArguments) < 1 {
+			log.Fatal("missing attributes. Usage: config get [attributes]")
+		}
 		attributes, err := parseAttributes(rawArguments[0])
 		if err != nil {
 			log.Fatal(err)
@@ -176,9 +512,10 @@ func main() {
 		if err != nil {
 			log.Fatal(err)
 		}
+		if stale := res.Header().Get(api.StaleSourcesHeader); stale != "" {
+			log.Printf("last known good content served for failed sources %v", stale)
+		}
 		log.Printf("%v: modified %v\n%v", res.Msg.GetHash(), res.Msg.GetNotModified(), res.Msg.GetContent())
-		// case addConfig:
-		// case removeConfig:
 	case listCollectors:
 		attributes := make(map[string]string)
 		if len(rawArguments) >= 1 {
@@ -197,14 +534,68 @@ func main() {
 		if err != nil {
 			log.Fatal(err)
 		}
+		for res.Receive() {
+			printCollector(res.Msg())
+		}
+		if err := res.Err(); err != nil {
+			log.Fatal(err)
+		}
+	case getCollector:
+		if len(rawArguments) < 1 {
+			log.Fatal("missing id. Usage: collector get [id]")
+		}
+		res, err := collectorClientAddon.GetCollector(
+			ctx,
+			connect.NewRequest(&serverv1.GetCollectorRequest{
+				Id: rawArguments[0],
+			}),
+		)
 		if err != nil {
 			log.Fatal(err)
 		}
-		log.Printf("%v: %v\n(%+v)", res.Msg().GetId(), res.Msg().GetName(), res.Msg().GetLocalAttributes())
-		for res.Receive() {
-			log.Printf("%v: %v\n(%+v)", res.Msg().GetId(), res.Msg().GetName(), res.Msg().GetLocalAttributes())
+		printCollector(res.Msg)
+	case overrideCollector:
+		const usage = "Usage: collector override [id] [source] [mode=replace|append] [ttl=duration]"
+		if len(rawArguments) < 2 {
+			log.Fatal("missing id or source. " + usage)
 		}
-	case getCollector:
+		req := &serverv1.SetCollectorOverrideRequest{
+			Id:     rawArguments[0],
+			Source: rawArguments[1],
+		}
+		for _, option := range rawArguments[2:] {
+			key, value, ok := strings.Cut(option, "=")
+			if !ok {
+				log.Fatalf("could not parse %v as option. %v", option, usage)
+			}
+			switch key {
+			case "mode":
+				req.Mode = value
+			case "ttl":
+				req.Ttl = value
+			default:
+				log.Fatalf("unknown option %v. %v", key, usage)
+			}
+		}
+		res, err := collectorClientAddon.SetCollectorOverride(ctx, connect.NewRequest(req))
+		if err != nil {
+			log.Fatal(err)
+		}
+		printCollector(res.Msg)
+	case resetCollector:
+		if len(rawArguments) < 1 {
+			log.Fatal("missing id. Usage: collector reset [id]")
+		}
+		res, err := collectorClientAddon.ClearCollectorOverride(
+			ctx,
+			connect.NewRequest(&serverv1.GetCollectorRequest{
+				Id: rawArguments[0],
+			}),
+		)
+		if err != nil {
+			log.Fatal(err)
+		}
+		printCollector(res.Msg)
 	case addCollector:
 	case removeCollector:
 	}
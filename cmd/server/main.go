@@ -4,23 +4,30 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
-	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/myLogic207/go-arcs/internal/args"
+	"github.com/myLogic207/go-arcs/internal/logfile"
+	"github.com/myLogic207/go-arcs/pkg/auth"
 	"github.com/myLogic207/go-arcs/pkg/mappings/collector"
 	"github.com/myLogic207/go-arcs/pkg/mappings/config"
+	"github.com/myLogic207/go-arcs/pkg/metrics"
 	"github.com/myLogic207/go-arcs/pkg/server"
 	"github.com/myLogic207/go-arcs/pkg/store"
 )
 
+const compactInterval = 10 * time.Minute
+
 var (
 	customFlags = map[string]args.Flag{
 		"config": {
@@ -28,6 +35,11 @@ var (
 			Value:   "mappings.yaml",
 			Message: "Specify the path to a config file or folder (includes all yml|yaml files)",
 		},
+		"reload-poll": {
+			Name:    "reload-poll",
+			Value:   "10s",
+			Message: "Interval to poll the config path for changes if file notifications are unavailable",
+		},
 		"port": {
 			Name:    "port",
 			Value:   8080,
@@ -38,18 +50,119 @@ var (
 			Value:   "0.0.0.0",
 			Message: "The IP Address to bind to, if none specified, binds to all ipv4",
 		},
+		"data": {
+			Name:  "data",
+			Value: "",
+			Message: `Directory to persist collectors and configs in, restored on start.
+If empty, everything is kept in memory only.`,
+		},
+		"git-mirrors": {
+			Name:    "git-mirrors",
+			Value:   "",
+			Message: "Directory to keep mirrors of git sources in, defaults to a temporary directory",
+		},
+		"s3-endpoints": {
+			Name:    "s3-endpoints",
+			Value:   "",
+			Message: "Comma separated endpoints s3 sources may set besides AWS_ENDPOINT_URL_S3, e.g. http://minio:9000",
+		},
+		"tokens": {
+			Name:  "tokens",
+			Value: "",
+			Message: `Path to a yaml file of hashed bearer tokens required for the management services.
+If empty, the management services are open to everyone.`,
+		},
+		"policy": {
+			Name:  "policy",
+			Value: "open",
+			Message: `How collectors are verified. 'open' trusts any caller,
+'token' binds collectors to the -tokens token with the enroll scope they registered with,
+'mtls' binds collectors to their client certificate (requires -client-ca).`,
+		},
+		"stale-after": {
+			Name:    "stale-after",
+			Value:   server.DefaultStaleAfter.String(),
+			Message: "Time without a poll after which a collector is reported stale",
+		},
+		"lost-after": {
+			Name:    "lost-after",
+			Value:   server.DefaultLostAfter.String(),
+			Message: "Time without a poll after which a collector is reported lost",
+		},
+		"collector-ttl": {
+			Name:  "collector-ttl",
+			Value: "0",
+			Message: `Time without a poll after which a collector is removed, 0 keeps collectors until they unregister.
+Removed collectors polling again are registered again.`,
+		},
+		"shutdown-delay": {
+			Name:    "shutdown-delay",
+			Value:   "0s",
+			Message: "Time to keep serving while reporting not ready before shutting down, lets load balancers catch up",
+		},
+		"tls-cert": {
+			Name:    "tls-cert",
+			Value:   "",
+			Message: "Path to a PEM certificate to serve TLS with, requires -tls-key",
+		},
+		"tls-key": {
+			Name:    "tls-key",
+			Value:   "",
+			Message: "Path to the PEM private key of -tls-cert",
+		},
+		"client-ca": {
+			Name:  "client-ca",
+			Value: "",
+			Message: `Path to PEM certificate authorities to verify client certificates with.
+If set, clients have to present a certificate and collectors can only register with an ID matching its CN or SANs.`,
+		},
+		"log-format": {
+			Name:    "log-format",
+			Value:   "logfmt",
+			Message: "Format of the log, 'logfmt' or 'json'",
+		},
+		"log-level": {
+			Name:    "log-level",
+			Value:   "info",
+			Message: "Minimum level to log, 'debug', 'info', 'warn' or 'error'",
+		},
 		"log": {
 			Name:  "log",
 			Value: "console",
 			Message: `Path to log to, if path is dir 'server.log' is appended.
-If Exists, will rotate. If an error occurs, console is used as fallback.
-Special value 'console' will force console.`,
+If Exists, will append. If an error occurs, console is used as fallback.
+Special value 'console' will force console. SIGUSR1 reopens the file, e.g. after logrotate moved it.`,
+		},
+		"log-max-size": {
+			Name:    "log-max-size",
+			Value:   100,
+			Message: "Size in megabytes after which the log file is rotated, 0 disables rotation",
+		},
+		"log-max-age": {
+			Name:    "log-max-age",
+			Value:   "0s",
+			Message: "Rotated log files older than this are removed, 0 keeps them",
+		},
+		"log-max-backups": {
+			Name:    "log-max-backups",
+			Value:   5,
+			Message: "Number of rotated log files to keep, 0 keeps all",
+		},
+		"log-compress": {
+			Name:    "log-compress",
+			Value:   false,
+			Message: "Gzip rotated log files",
+		},
+		"config-history": {
+			Name:    "config-history",
+			Value:   config.HistorySize,
+			Message: "Number of content versions kept per config mapping to pin to",
+		},
+		"validate": {
+			Name:    "validate",
+			Value:   false,
+			Message: "Validate the config mappings and their sources, print a report and exit non-zero on failure",
 		},
-		// args.Flag{
-		// 	Name:    "validate",
-		// 	Value:   false,
-		// 	Message: "Flag to validate the configuration and the stop",
-		// },
 	}
 )
 
@@ -59,58 +172,134 @@ func cleanup(ctx context.Context, cancel context.CancelFunc, sigs chan os.Signal
 		select {
 		case <-ctx.Done():
 			cancel()
-			log.Println("System stopped")
+			slog.Info("System stopped")
 			done <- true
 		case sig := <-sigs:
-			log.Printf("Received signal %v", sig)
+			slog.Info("Received signal", "signal", sig)
 			done <- true
 		}
 	}
 }
 
-func getLogFile(name string) (*os.File, error) {
-	if name == "console" {
-		return os.Stdout, errors.New("forced console logging")
+func reloadOnHangup(ctx context.Context, reloader *config.Reloader) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			diff, err := reloader.Reload(ctx)
+			if err != nil {
+				slog.Error("Failed to reload configs", "reason", "SIGHUP", "error", err)
+				continue
+			}
+			slog.Info("Reloaded configs", "reason", "SIGHUP", "added", diff.Added, "updated", diff.Updated, "removed", diff.Removed)
+		}
 	}
+}
+
+// logs err and exits, deferred calls are not run
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// creates a logger writing json or logfmt from level on
+func newLogger(w io.Writer, format string, level string) (*slog.Logger, error) {
+	var minLevel slog.Level
+	if err := minLevel.UnmarshalText([]byte(level)); err != nil {
+		return nil, err
+	}
+	options := &slog.HandlerOptions{Level: minLevel}
+	switch strings.ToLower(format) {
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	case "logfmt", "text":
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %v, use 'json' or 'logfmt'", format)
+	}
+}
 
-	logPath, err := filepath.Abs(name)
+// validates the mappings at path, prints a report and returns the exit code
+func validate(ctx context.Context, path string) int {
+	configs, err := config.Load(ctx, path)
 	if err != nil {
-		return os.Stdout, err
+		fmt.Printf("FAIL\tload %v: %v\n", path, err)
+		return 1
 	}
+	report := config.Validate(ctx, configs)
+	printReport(os.Stdout, report)
+	if report.Failed() {
+		return 1
+	}
+	return 0
+}
 
-	stat, err := os.Stat(logPath)
-	if err != nil && !errors.Is(err, os.ErrNotExist) {
-		return os.Stdout, err
+func printReport(w io.Writer, report config.Report) {
+	failed := 0
+	status := func(err error) string {
+		if err != nil {
+			failed++
+			return "FAIL"
+		}
+		return "ok"
 	}
-	err = nil
-	if stat.IsDir() {
-		logPath = filepath.Join(logPath, "server.log")
-		stat, err = os.Stat(logPath)
-		if err != nil && !errors.Is(err, os.ErrNotExist) {
-			return os.Stdout, err
+	for _, source := range report.Sources {
+		fmt.Fprintf(w, "%v\t%v\n", status(source.Err), source.Source)
+		if source.Err != nil {
+			fmt.Fprintf(w, "\t%v\n", strings.ReplaceAll(source.Err.Error(), "\n", "\n\t"))
 		}
 	}
-
-	if err == nil && stat.Size() > 0 {
-		logPathPart, ok := strings.CutSuffix(logPath, ".log")
-		//Reference Time is: 01/02 03:04:05PM 2006 MST = January 2, 2006 at 3:04:05 PM MST
-		timestamp := time.Now().Format("2006-01-02.03-04-05")
-		var logPathNew string
-		if ok {
-			logPathNew = fmt.Sprintf("%v.%v.log", logPathPart, timestamp)
-		} else {
-			logPathNew = fmt.Sprintf("%v.%v", logPathPart, timestamp)
+	for _, overlap := range report.Overlaps {
+		attributes := make([]string, 0, len(overlap.Attributes))
+		for key, val := range overlap.Attributes {
+			attributes = append(attributes, key+"="+val)
+		}
+		slices.Sort(attributes)
+		fmt.Fprintf(w, "%v\toverlap %v\n", status(overlap.Err), strings.Join(attributes, ","))
+		for _, source := range overlap.Sources {
+			fmt.Fprintf(w, "\t- %v\n", source)
+		}
+		if overlap.Err != nil {
+			fmt.Fprintf(w, "\t%v\n", strings.ReplaceAll(overlap.Err.Error(), "\n", "\n\t"))
 		}
-		// rotate old log
-		if err := os.Rename(logPath, logPathNew); err != nil {
-			return nil, err
+	}
+	fmt.Fprintf(w, "%v sources, %v overlaps, %v failed\n", len(report.Sources), len(report.Overlaps), failed)
+}
+
+// opens the log file, console forces logging to stdout
+func openLog(name string, options logfile.Options) (*logfile.File, error) {
+	if name == "console" {
+		return nil, errors.New("forced console logging")
+	}
+	return logfile.Open(name, options)
+}
+
+// reopens the log file on SIGUSR1, for rotation by external tools
+func reopenOnUser1(ctx context.Context, file *logfile.File) {
+	user1 := make(chan os.Signal, 1)
+	signal.Notify(user1, syscall.SIGUSR1)
+	defer signal.Stop(user1)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-user1:
+			if err := file.Reopen(); err != nil {
+				// the logger may write to the failed file
+				fmt.Fprintf(os.Stderr, "failed to reopen log file: %v\n", err)
+				continue
+			}
+			slog.Info("Reopened log file", "path", file.Path())
 		}
 	}
-	return os.Create(logPath)
 }
 
 func main() {
-	log.Print("Starting...")
+	slog.Info("Starting...")
 	mainCtx := context.Background()
 	ctx, cancel := context.WithCancel(mainCtx)
 	done := make(chan bool, 1)
@@ -118,59 +307,217 @@ func main() {
 	go cleanup(ctx, cancel, sigs, done)
 
 	flags, _ := args.Init(customFlags)
-	logFile, err := getLogFile(*flags["log"].(*string))
+	maxAge, err := time.ParseDuration(*flags["log-max-age"].(*string))
 	if err != nil {
-		log.Println("Log (file) path not found/readable, falling back to console:", err)
-		logFile = os.Stdout
-	} else {
+		fatal("Invalid log max age", err)
+	}
+	var logWriter io.Writer = os.Stdout
+	logFile, fileErr := openLog(*flags["log"].(*string), logfile.Options{
+		MaxSize:    int64(*flags["log-max-size"].(*int)) << 20,
+		MaxAge:     maxAge,
+		MaxBackups: *flags["log-max-backups"].(*int),
+		Compress:   *flags["log-compress"].(*bool),
+	})
+	if fileErr == nil {
+		logWriter = logFile
 		defer logFile.Close()
+		go reopenOnUser1(ctx, logFile)
+	}
+	logger, err := newLogger(logWriter, *flags["log-format"].(*string), *flags["log-level"].(*string))
+	if err != nil {
+		fatal("Invalid logging flags", err)
+	}
+	// also routes the log package of other packages through the logger
+	slog.SetDefault(logger)
+	if fileErr != nil {
+		slog.Info("Log (file) path not found/readable, falling back to console", "reason", fileErr)
+	}
+
+	if mirrorDir := *flags["git-mirrors"].(*string); mirrorDir != "" {
+		config.GitMirrorDir = mirrorDir
+	}
+	config.HistorySize = *flags["config-history"].(*int)
+	if endpoints := *flags["s3-endpoints"].(*string); endpoints != "" {
+		config.S3Endpoints = strings.Split(endpoints, ",")
 	}
-	log.SetOutput(logFile)
 
 	configPath := flags["config"].(*string)
-	log.Printf("Loading configs from %v", *configPath)
+	if *flags["validate"].(*bool) {
+		os.Exit(validate(ctx, *configPath))
+	}
+	slog.Info("Loading configs", "path", *configPath)
 	initConfigs, err := config.Load(ctx, *configPath)
 	if err != nil {
 		cancel()
-		log.Fatal(err)
+		fatal("Failed to load configs", err)
+	}
+	slog.Info("Loaded configs, creating store", "configs", len(initConfigs))
+	var initConfigStore config.Store
+	var collectorStore collector.Store
+	if dataDir := *flags["data"].(*string); dataDir != "" {
+		slog.Info("Persisting state", "dir", dataDir)
+		initConfigStore, err = store.NewDiskStore(ctx, dataDir, "configs", config.Codec{}, compactInterval)
+		if err != nil {
+			cancel()
+			fatal("Failed to open config store", err)
+		}
+		collectorStore, err = store.NewDiskStore(ctx, dataDir, "collectors", collector.Codec{}, compactInterval)
+		if err != nil {
+			cancel()
+			fatal("Failed to open collector store", err)
+		}
+	} else {
+		initConfigStore = store.NewStore[config.Config](nil, nil)
+		collectorStore = store.NewStore(
+			store.ObjectStore[collector.Collector]{},
+			store.MappingStore{},
+		)
+	}
+	// restored configs keep their rollouts and pins
+	for _, conf := range initConfigs {
+		if restored := initConfigStore.Get(ctx, conf.ID()); restored != nil {
+			config.Inherit(conf, restored)
+		}
 	}
-	log.Printf("Loaded %v configs, creating store", len(initConfigs))
-	initConfigStore := store.NewStore[config.Config](nil, nil)
 	if _, err := initConfigStore.Load(ctx, initConfigs); err != nil {
 		cancel()
-		log.Fatal(err)
+		fatal("Failed to store configs", err)
 	}
 
-	log.Print("Created init config store")
-
-	collectorStore := store.NewStore(
-		store.ObjectStore[collector.Collector]{},
-		store.MappingStore{},
+	slog.Info("Created init config store")
+	metrics.Registry.MustRegister(
+		metrics.StoreSize("configs_loaded", "Config mappings in the store.", initConfigStore),
+		metrics.StoreSize("collectors_registered", "Registered collectors.", collectorStore),
 	)
-	log.Print("Created collector store")
+
+	// reloads and the management services change configs one at a time
+	mappingsMu := &sync.Mutex{}
+	reloader := config.NewReloader(ctx, *configPath, initConfigStore, initConfigs, mappingsMu)
+	// drops restored configs of mappings deleted while the server was down
+	if diff, err := reloader.Reload(ctx); err != nil {
+		slog.Error("Failed to reconcile restored configs", "error", err)
+	} else if len(diff.Removed) > 0 {
+		slog.Info("Removed restored configs no longer mapped", "removed", diff.Removed)
+	}
+	pollInterval, err := time.ParseDuration(*flags["reload-poll"].(*string))
+	if err != nil {
+		cancel()
+		fatal("Invalid reload poll interval", err)
+	}
+	go reloader.Watch(ctx, pollInterval)
+	go reloadOnHangup(ctx, reloader)
+	slog.Info("Created collector store", "collectors", len(collectorStore.List(ctx)))
 
 	address := fmt.Sprintf("%v:%v", *flags["addr"].(*string), *flags["port"].(*int))
 	listener, err := net.Listen("tcp", address)
 	if err != nil {
 		cancel()
-		log.Fatal(err)
+		fatal("Failed to listen", err)
 	}
-	s := server.New(address, initConfigStore, collectorStore)
+	staleAfter, err := time.ParseDuration(*flags["stale-after"].(*string))
+	if err != nil {
+		cancel()
+		fatal("Invalid stale threshold", err)
+	}
+	lostAfter, err := time.ParseDuration(*flags["lost-after"].(*string))
+	if err != nil {
+		cancel()
+		fatal("Invalid lost threshold", err)
+	}
+	collectorTTL, err := time.ParseDuration(*flags["collector-ttl"].(*string))
+	if err != nil {
+		cancel()
+		fatal("Invalid collector ttl", err)
+	}
+	shutdownDelay, err := time.ParseDuration(*flags["shutdown-delay"].(*string))
+	if err != nil {
+		cancel()
+		fatal("Invalid shutdown delay", err)
+	}
+	options := []server.Option{
+		server.WithHealthThresholds(staleAfter, lostAfter),
+		server.WithReaper(collectorTTL),
+		server.WithReadinessCheck("reload", func(context.Context) error {
+			return reloader.LastError()
+		}),
+	}
+	if tokenPath := *flags["tokens"].(*string); tokenPath != "" {
+		tokens, err := auth.LoadTokens(tokenPath)
+		if err != nil {
+			cancel()
+			fatal("Failed to load tokens", err)
+		}
+		slog.Info("Loaded tokens for the management services", "tokens", tokens.Len())
+		options = append(options, server.WithTokens(tokens))
+	} else {
+		slog.Warn("No tokens configured, management services are unauthenticated")
+	}
+	certFile, keyFile := *flags["tls-cert"].(*string), *flags["tls-key"].(*string)
+	clientCA := *flags["client-ca"].(*string)
+	if certFile != "" || keyFile != "" {
+		tlsConfig, err := auth.ServerTLSConfig(certFile, keyFile, clientCA)
+		if err != nil {
+			cancel()
+			fatal("Failed to load TLS certificates", err)
+		}
+		options = append(options, server.WithTLS(tlsConfig))
+		slog.Info("Serving TLS", "client_certificates", clientCA != "")
+	} else if clientCA != "" {
+		cancel()
+		fatal("Invalid flags", errors.New("-client-ca requires -tls-cert and -tls-key"))
+	}
+	policy, err := auth.ParsePolicy(*flags["policy"].(*string))
+	if err != nil {
+		cancel()
+		fatal("Invalid policy", err)
+	}
+	switch {
+	case policy == auth.PolicyToken && *flags["tokens"].(*string) == "":
+		cancel()
+		fatal("Invalid flags", errors.New("-policy token requires -tokens"))
+	case policy == auth.PolicyMTLS && clientCA == "":
+		cancel()
+		fatal("Invalid flags", errors.New("-policy mtls requires -client-ca"))
+	}
+	slog.Info("Collector registration policy", "policy", policy.String())
+	options = append(options, server.WithPolicy(policy), server.WithMappingsLock(mappingsMu))
+	s := server.New(
+		address,
+		initConfigStore,
+		collectorStore,
+		options...,
+	)
+
+	go s.RunReaper(ctx)
+	go s.RunRollouts(ctx)
 
-	log.Print("Starting Server")
+	slog.Info("Starting Server")
 	go func() {
 		err := s.Serve(listener)
 		if err != nil && err != http.ErrServerClosed {
-			log.Fatalf("listen failed: %v", err)
+			fatal("Failed to serve", err)
 		}
 	}()
-	log.Printf("Server listening on %v and ready to accept connections", address)
+	s.SetReady(true)
+	slog.Info("Server listening and ready to accept connections", "address", address)
 
 	<-done
 
+	s.SetReady(false)
+	if shutdownDelay > 0 {
+		slog.Info("Reporting not ready before shutting down", "delay", shutdownDelay)
+		time.Sleep(shutdownDelay)
+	}
+
 	if err := s.Shutdown(ctx); err != nil {
-		log.Printf("Failed to stop Server: %v", err)
+		slog.Error("Failed to stop Server", "error", err)
 	}
 	// listener.Close()
 	cancel()
+	// persistent stores write their final state once ctx is done
+	for _, persisted := range []any{initConfigStore, collectorStore} {
+		if waiter, ok := persisted.(store.Waiter); ok {
+			waiter.Wait()
+		}
+	}
 }
@@ -0,0 +1,266 @@
+package logfile
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// file name used if the path is a directory
+	defaultName = "server.log"
+	// sortable timestamp appended to rotated files
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	compressSuffix   = ".gz"
+)
+
+var (
+	ErrOpen   = errors.New("could not open log file")
+	ErrRotate = errors.New("could not rotate log file")
+	ErrClosed = errors.New("log file is closed")
+)
+
+// Options of rotating a log file, zero values disable the respective limit
+type Options struct {
+	// size in bytes after which the file is rotated
+	MaxSize int64
+	// rotated files older than this are removed
+	MaxAge time.Duration
+	// number of rotated files to keep
+	MaxBackups int
+	// gzip rotated files
+	Compress bool
+}
+
+// File is a log file that rotates itself by size and prunes old rotated files,
+// it is safe for concurrent use
+type File struct {
+	path    string
+	options Options
+	file    *os.File
+	size    int64
+	mu      sync.Mutex
+	// serializes compressing and pruning of rotated files
+	cleanMu sync.Mutex
+	wg      sync.WaitGroup
+	now     func() time.Time
+}
+
+// Open appends to the log file at path, or to server.log if path is a directory
+func Open(path string, options Options) (*File, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.Join(ErrOpen, err)
+	}
+	if stat, err := os.Stat(path); err == nil && stat.IsDir() {
+		path = filepath.Join(path, defaultName)
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, errors.Join(ErrOpen, err)
+	}
+
+	f := &File{
+		path:    path,
+		options: options,
+		now:     time.Now,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Path returns the path of the current log file
+func (f *File) Path() string {
+	return f.path
+}
+
+func (f *File) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o750); err != nil {
+		return errors.Join(ErrOpen, err)
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return errors.Join(ErrOpen, err)
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return errors.Join(ErrOpen, err)
+	}
+	f.file = file
+	f.size = stat.Size()
+	return nil
+}
+
+// Write appends p to the log file, rotating it first if p would exceed the max size
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return 0, ErrClosed
+	}
+	if f.options.MaxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.options.MaxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate moves the current log file aside and starts a new one
+func (f *File) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return ErrClosed
+	}
+	return f.rotate()
+}
+
+func (f *File) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return errors.Join(ErrRotate, err)
+	}
+	f.file = nil
+	if err := os.Rename(f.path, f.backupName(f.now())); err != nil && !errors.Is(err, os.ErrNotExist) {
+		// keep writing to the old file rather than losing logs
+		if openErr := f.open(); openErr != nil {
+			return errors.Join(ErrRotate, err, openErr)
+		}
+		return errors.Join(ErrRotate, err)
+	}
+	if err := f.open(); err != nil {
+		return errors.Join(ErrRotate, err)
+	}
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		f.cleanup()
+	}()
+	return nil
+}
+
+// Reopen closes and opens the log file again, for log files moved by external tools
+func (f *File) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return ErrClosed
+	}
+	if err := f.file.Close(); err != nil {
+		return errors.Join(ErrOpen, err)
+	}
+	f.file = nil
+	return f.open()
+}
+
+// Close closes the log file after pending compression and pruning is done
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.wg.Wait()
+	if f.file == nil {
+		return ErrClosed
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// name of a rotated file, e.g. server-2006-01-02T15-04-05.000.log
+func (f *File) backupName(at time.Time) string {
+	ext := filepath.Ext(f.path)
+	return fmt.Sprintf("%v-%v%v", strings.TrimSuffix(f.path, ext), at.UTC().Format(backupTimeFormat), ext)
+}
+
+type backup struct {
+	path string
+	at   time.Time
+}
+
+// rotated files of the log file, newest first
+func (f *File) backups() ([]backup, error) {
+	ext := filepath.Ext(f.path)
+	prefix := filepath.Base(strings.TrimSuffix(f.path, ext)) + "-"
+	entries, err := os.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return nil, err
+	}
+	var backups []backup
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), compressSuffix)
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		raw := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+		at, err := time.Parse(backupTimeFormat, raw)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{filepath.Join(filepath.Dir(f.path), entry.Name()), at})
+	}
+	slices.SortFunc(backups, func(a, b backup) int {
+		return b.at.Compare(a.at)
+	})
+	return backups, nil
+}
+
+// compresses and prunes rotated files, errors are written to stderr
+// as the log itself may be what fails
+func (f *File) cleanup() {
+	f.cleanMu.Lock()
+	defer f.cleanMu.Unlock()
+	backups, err := f.backups()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to list rotated log files: %v\n", err)
+		return
+	}
+	for i, backup := range backups {
+		expired := f.options.MaxAge > 0 && f.now().Sub(backup.at) > f.options.MaxAge
+		surplus := f.options.MaxBackups > 0 && i >= f.options.MaxBackups
+		if expired || surplus {
+			if err := os.Remove(backup.path); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to remove rotated log file: %v\n", err)
+			}
+			continue
+		}
+		if f.options.Compress && !strings.HasSuffix(backup.path, compressSuffix) {
+			if err := compress(backup.path); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to compress rotated log file: %v\n", err)
+			}
+		}
+	}
+}
+
+// replaces the file at path by a gzipped copy
+func compress(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(path+compressSuffix, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return err
+	}
+	writer := gzip.NewWriter(dst)
+	if _, err := io.Copy(writer, src); err != nil {
+		dst.Close()
+		os.Remove(dst.Name())
+		return err
+	}
+	if err := errors.Join(writer.Close(), dst.Close()); err != nil {
+		os.Remove(dst.Name())
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,171 @@
+package logfile
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// returns a clock advancing a second each call
+func stepClock(start time.Time) func() time.Time {
+	now := start
+	return func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+}
+
+func Test_OpenMissingPath(t *testing.T) {
+	dir := t.TempDir()
+	file, err := Open(filepath.Join(dir, "nested", "arcs.log"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Write([]byte("line\n")); err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, file.Close())
+
+	content, err := os.ReadFile(filepath.Join(dir, "nested", "arcs.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "line\n", string(content))
+}
+
+func Test_OpenDirAppends(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, defaultName)
+	if err := os.WriteFile(path, []byte("old\n"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	file, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, path, file.Path())
+	file.Write([]byte("new\n"))
+	file.Close()
+
+	content, _ := os.ReadFile(path)
+	assert.Equal(t, "old\nnew\n", string(content))
+}
+
+func Test_RotateBySize(t *testing.T) {
+	dir := t.TempDir()
+	file, err := Open(filepath.Join(dir, "server.log"), Options{MaxSize: 10, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.now = stepClock(time.Now())
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file.Close()
+
+	content, _ := os.ReadFile(filepath.Join(dir, "server.log"))
+	assert.Equal(t, "fourth\n", string(content))
+	backups, err := file.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// first is pruned, newest first
+	if assert.Len(t, backups, 2) {
+		third, _ := os.ReadFile(backups[0].path)
+		second, _ := os.ReadFile(backups[1].path)
+		assert.Equal(t, "third\n", string(third))
+		assert.Equal(t, "second\n", string(second))
+	}
+}
+
+func Test_PruneByAge(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Hour)
+	file, err := Open(filepath.Join(dir, "server.log"), Options{MaxAge: 30 * time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.now = func() time.Time { return start }
+	file.Write([]byte("old\n"))
+	if err := file.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	file.wg.Wait()
+	file.now = time.Now
+	file.Write([]byte("new\n"))
+	if err := file.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	backups, _ := file.backups()
+	if assert.Len(t, backups, 1) {
+		content, _ := os.ReadFile(backups[0].path)
+		assert.Equal(t, "new\n", string(content))
+	}
+}
+
+func Test_Compress(t *testing.T) {
+	dir := t.TempDir()
+	file, err := Open(filepath.Join(dir, "server.log"), Options{Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte("rotated\n"))
+	if err := file.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	backups, _ := file.backups()
+	if !assert.Len(t, backups, 1) {
+		return
+	}
+	assert.True(t, strings.HasSuffix(backups[0].path, compressSuffix))
+	raw, err := os.Open(backups[0].path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+	reader, err := gzip.NewReader(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := io.ReadAll(reader)
+	assert.Equal(t, "rotated\n", string(content))
+}
+
+func Test_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.log")
+	file, err := Open(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte("before\n"))
+	// external rotation
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte("after\n"))
+	file.Close()
+
+	before, _ := os.ReadFile(path + ".1")
+	after, _ := os.ReadFile(path)
+	assert.Equal(t, "before\n", string(before))
+	assert.Equal(t, "after\n", string(after))
+
+	_, err = file.Write([]byte("closed\n"))
+	assert.ErrorIs(t, err, ErrClosed)
+}
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"connectrpc.com/connect"
+)
+
+const bearerPrefix = "Bearer "
+
+var (
+	ErrUnauthenticated  = errors.New("missing or invalid bearer token")
+	ErrPermissionDenied = errors.New("token lacks scope")
+)
+
+type tokenKey struct{}
+
+// TokenFromContext returns the token a request was authenticated with
+func TokenFromContext(ctx context.Context) (Token, bool) {
+	token, ok := ctx.Value(tokenKey{}).(Token)
+	return token, ok
+}
+
+// RequiredScope returns the scope needed to call a procedure,
+// procedures without side effects only need read access
+func RequiredScope(spec connect.Spec) Scope {
+	if spec.IdempotencyLevel == connect.IdempotencyNoSideEffects {
+		return ScopeRead
+	}
+	return ScopeWrite
+}
+
+type interceptor struct {
+	tokens *Tokens
+	scope  func(connect.Spec) Scope
+}
+
+// NewInterceptor requires handlers to be called with a bearer token
+// that has the scope required by the procedure
+func NewInterceptor(tokens *Tokens) connect.Interceptor {
+	return &interceptor{tokens: tokens, scope: RequiredScope}
+}
+
+// NewEnrollInterceptor requires handlers to be called with a bearer token
+// that has the enroll scope
+func NewEnrollInterceptor(tokens *Tokens) connect.Interceptor {
+	return &interceptor{
+		tokens: tokens,
+		scope:  func(connect.Spec) Scope { return ScopeEnroll },
+	}
+}
+
+func (i *interceptor) authenticate(ctx context.Context, spec connect.Spec, header http.Header) (context.Context, error) {
+	raw, ok := strings.CutPrefix(header.Get("Authorization"), bearerPrefix)
+	if !ok {
+		return nil, connect.NewError(connect.CodeUnauthenticated, ErrUnauthenticated)
+	}
+	token, err := i.tokens.Authenticate(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnauthenticated, ErrUnauthenticated)
+	}
+	scope := i.scope(spec)
+	if !token.Allows(scope) {
+		return nil, connect.NewError(
+			connect.CodePermissionDenied,
+			fmt.Errorf("%w %v for %v", ErrPermissionDenied, scope, spec.Procedure),
+		)
+	}
+	return context.WithValue(ctx, tokenKey{}, token), nil
+}
+
+func (i *interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		ctx, err := i.authenticate(ctx, req.Spec(), req.Header())
+		if err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+func (i *interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		ctx, err := i.authenticate(ctx, conn.Spec(), conn.RequestHeader())
+		if err != nil {
+			return err
+		}
+		return next(ctx, conn)
+	}
+}
+
+type bearerToken struct {
+	token string
+}
+
+// NewBearerToken sends token with every request of a client
+func NewBearerToken(token string) connect.Interceptor {
+	return &bearerToken{token: token}
+}
+
+func (b *bearerToken) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			req.Header().Set("Authorization", bearerPrefix+b.token)
+		}
+		return next(ctx, req)
+	}
+}
+
+func (b *bearerToken) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		conn := next(ctx, spec)
+		conn.RequestHeader().Set("Authorization", bearerPrefix+b.token)
+		return conn
+	}
+}
+
+func (b *bearerToken) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next
+}
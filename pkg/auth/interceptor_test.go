@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	serverv1 "github.com/myLogic207/go-arcs/api/gen/proto/go/server/v1"
+	"github.com/myLogic207/go-arcs/api/gen/proto/go/server/v1/serverv1connect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterceptor(t *testing.T) {
+	tokens, err := ParseTokens([]byte(fmt.Sprintf(
+		"tokens:\n  - name: reader\n    hash: %v\n    scopes: [read]\n  - name: admin\n    hash: %v\n    scopes: [read, write]\n",
+		HashToken("read-secret"),
+		HashToken("admin-secret"),
+	)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle(serverv1connect.NewConfigManagerHandler(
+		serverv1connect.UnimplementedConfigManagerHandler{},
+		connect.WithInterceptors(NewInterceptor(tokens)),
+	))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		token    string
+		write    bool
+		wantCode connect.Code
+	}{
+		{name: "missing token", wantCode: connect.CodeUnauthenticated},
+		{name: "invalid token", token: "unknown", wantCode: connect.CodeUnauthenticated},
+		// passing authentication reaches the unimplemented handler
+		{name: "read with read scope", token: "read-secret", wantCode: connect.CodeUnimplemented},
+		{name: "write with read scope", token: "read-secret", write: true, wantCode: connect.CodePermissionDenied},
+		{name: "write with write scope", token: "admin-secret", write: true, wantCode: connect.CodeUnimplemented},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var options []connect.ClientOption
+			if tt.token != "" {
+				options = append(options, connect.WithInterceptors(NewBearerToken(tt.token)))
+			}
+			client := serverv1connect.NewConfigManagerClient(server.Client(), server.URL, options...)
+			ctx := context.Background()
+			var err error
+			if tt.write {
+				_, err = client.AddConfig(ctx, connect.NewRequest(&serverv1.ConfigMappingRequest{}))
+			} else {
+				_, err = client.GetConfigMapping(ctx, connect.NewRequest(&serverv1.ConfigSourceRequest{}))
+			}
+			assert.Equal(t, tt.wantCode, connect.CodeOf(err))
+
+			// streams are checked the same way
+			if !tt.write {
+				stream, err := client.ListConfigs(ctx, connect.NewRequest(&serverv1.ListRequest{}))
+				if err != nil {
+					t.Fatal(err)
+				}
+				for stream.Receive() {
+				}
+				assert.Equal(t, tt.wantCode, connect.CodeOf(stream.Err()))
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/myLogic207/go-arcs/pkg/store"
+)
+
+// Policy decides what collectors are bound to on registration
+type Policy int
+
+const (
+	// any caller may register and fetch configs for any ID
+	PolicyOpen Policy = iota
+	// collectors are bound to the hash of the enrollment token they registered with,
+	// every holder of a shared enrollment token can act as any collector enrolled with it
+	PolicyToken
+	// collectors are bound to the identity of their client certificate
+	PolicyMTLS
+)
+
+var (
+	ErrPolicyUnknown     = errors.New("unknown registration policy, use 'open', 'token' or 'mtls'")
+	ErrMissingCredential = errors.New("request carries no credential required by the registration policy")
+)
+
+func ParsePolicy(raw string) (Policy, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", "open":
+		return PolicyOpen, nil
+	case "token":
+		return PolicyToken, nil
+	case "mtls":
+		return PolicyMTLS, nil
+	default:
+		return PolicyOpen, fmt.Errorf("%w: %v", ErrPolicyUnknown, raw)
+	}
+}
+
+func (p Policy) String() string {
+	switch p {
+	case PolicyToken:
+		return "token"
+	case PolicyMTLS:
+		return "mtls"
+	default:
+		return "open"
+	}
+}
+
+// Credential returns the fingerprint of the credential of a request for collector id,
+// it is empty for the open policy
+func (p Policy) Credential(ctx context.Context, id string) (string, error) {
+	switch p {
+	case PolicyToken:
+		token, ok := TokenFromContext(ctx)
+		if !ok {
+			return "", ErrMissingCredential
+		}
+		return token.Hash, nil
+	case PolicyMTLS:
+		identity, ok := IdentityFromContext(ctx)
+		if !ok {
+			return "", ErrMissingCredential
+		}
+		if !identity.Matches(id) {
+			return "", fmt.Errorf("%w %v, certificate names %v", ErrIdentity, id, identity.Names())
+		}
+		// survives certificate rotation as long as the names stay the same
+		names := identity.Names()
+		slices.Sort(names)
+		return "cert:" + store.Hash([]byte(strings.Join(names, ","))), nil
+	default:
+		return "", nil
+	}
+}
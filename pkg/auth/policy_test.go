@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyCredential(t *testing.T) {
+	token := Token{Name: "collectors", Hash: HashToken("secret"), Scopes: []Scope{ScopeEnroll}}
+	identity := Identity{CommonName: "collector-1", DNSNames: []string{"collector-1.example.com"}}
+	rotated := Identity{CommonName: "collector-1", DNSNames: []string{"collector-1.example.com"}}
+	background := context.Background()
+	withToken := context.WithValue(background, tokenKey{}, token)
+	withIdentity := context.WithValue(background, identityKey{}, identity)
+
+	tests := []struct {
+		name    string
+		policy  Policy
+		ctx     context.Context
+		id      string
+		want    string
+		wantErr error
+	}{
+		{name: "open", policy: PolicyOpen, ctx: withToken, id: "collector-1"},
+		{name: "token", policy: PolicyToken, ctx: withToken, id: "collector-1", want: token.Hash},
+		{name: "token missing", policy: PolicyToken, ctx: background, id: "collector-1", wantErr: ErrMissingCredential},
+		{name: "mtls missing", policy: PolicyMTLS, ctx: background, id: "collector-1", wantErr: ErrMissingCredential},
+		{name: "mtls other id", policy: PolicyMTLS, ctx: withIdentity, id: "collector-2", wantErr: ErrIdentity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.policy.Credential(tt.ctx, tt.id)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	first, err := PolicyMTLS.Credential(withIdentity, "collector-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := PolicyMTLS.Credential(context.WithValue(background, identityKey{}, rotated), "collector-1.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEmpty(t, first)
+	assert.Equal(t, first, second)
+}
+
+func TestParsePolicy(t *testing.T) {
+	for raw, want := range map[string]Policy{"": PolicyOpen, "open": PolicyOpen, "token": PolicyToken, "MTLS": PolicyMTLS} {
+		got, err := ParsePolicy(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, want, got)
+	}
+	_, err := ParsePolicy("secret")
+	assert.ErrorIs(t, err, ErrPolicyUnknown)
+}
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"os"
+	"slices"
+)
+
+var (
+	ErrLoadCertificate = errors.New("could not load certificate")
+	ErrLoadCA          = errors.New("could not load certificate authority")
+	ErrIdentity        = errors.New("certificate does not match collector")
+)
+
+// Identity is the subject of a verified client certificate
+type Identity struct {
+	CommonName string
+	DNSNames   []string
+	URIs       []string
+	Emails     []string
+}
+
+// Names returns all names of the certificate, common name first
+func (i Identity) Names() []string {
+	names := make([]string, 0, 1+len(i.DNSNames)+len(i.URIs)+len(i.Emails))
+	if i.CommonName != "" {
+		names = append(names, i.CommonName)
+	}
+	names = append(names, i.DNSNames...)
+	names = append(names, i.URIs...)
+	return append(names, i.Emails...)
+}
+
+// Matches reports if the common name or any subject alternative name is id
+func (i Identity) Matches(id string) bool {
+	return id != "" && slices.Contains(i.Names(), id)
+}
+
+type identityKey struct{}
+
+// IdentityFromContext returns the identity of the verified client certificate of a request
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
+
+// WithPeerIdentity makes the identity of verified client certificates available to handlers
+func WithPeerIdentity(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cert := r.TLS.VerifiedChains[0][0]
+		identity := Identity{
+			CommonName: cert.Subject.CommonName,
+			DNSNames:   cert.DNSNames,
+			Emails:     cert.EmailAddresses,
+		}
+		for _, uri := range cert.URIs {
+			identity.URIs = append(identity.URIs, uri.String())
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), identityKey{}, identity)))
+	})
+}
+
+// ServerTLSConfig loads the server certificate,
+// client certificates are required and verified if clientCA is set
+func ServerTLSConfig(certFile string, keyFile string, clientCA string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, errors.Join(ErrLoadCertificate, err)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	if clientCA != "" {
+		pool, err := loadCertPool(clientCA)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// ClientTLSConfig verifies the server with ca if set, otherwise with the system pool,
+// the client certificate is sent if certFile and keyFile are set
+func ClientTLSConfig(ca string, certFile string, keyFile string) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if ca != "" {
+		pool, err := loadCertPool(ca)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, errors.Join(ErrLoadCertificate, err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Join(ErrLoadCA, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(raw) {
+		return nil, ErrLoadCA
+	}
+	return pool, nil
+}
@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writes a certificate signed by parent, or self signed if parent is nil, and returns it with its key
+func writeCert(
+	t *testing.T,
+	dir string,
+	name string,
+	template *x509.Certificate,
+	parent *x509.Certificate,
+	parentKey *ecdsa.PrivateKey,
+) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	raw, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawKey, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, block := range map[string]*pem.Block{
+		name + ".pem":     {Type: "CERTIFICATE", Bytes: raw},
+		name + "-key.pem": {Type: "EC PRIVATE KEY", Bytes: rawKey},
+	} {
+		if err := os.WriteFile(filepath.Join(dir, path), pem.EncodeToMemory(block), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return cert, key
+}
+
+func TestPeerIdentity(t *testing.T) {
+	dir := t.TempDir()
+	notAfter := time.Now().Add(time.Hour)
+	ca, caKey := writeCert(t, dir, "ca", &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+	writeCert(t, dir, "server", &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "arcs"},
+		NotAfter:     notAfter,
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca, caKey)
+	writeCert(t, dir, "client", &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "collector-1"},
+		DNSNames:     []string{"collector-1.example.com"},
+		NotAfter:     notAfter,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+
+	serverConfig, err := ServerTLSConfig(
+		filepath.Join(dir, "server.pem"),
+		filepath.Join(dir, "server-key.pem"),
+		filepath.Join(dir, "ca.pem"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewUnstartedServer(WithPeerIdentity(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, ok := IdentityFromContext(r.Context())
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		io.WriteString(w, strings.Join(identity.Names(), ","))
+	})))
+	server.TLS = serverConfig
+	server.StartTLS()
+	defer server.Close()
+
+	clientConfig, err := ClientTLSConfig(
+		filepath.Join(dir, "ca.pem"),
+		filepath.Join(dir, "client.pem"),
+		filepath.Join(dir, "client-key.pem"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientConfig, ForceAttemptHTTP2: true}}
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "HTTP/2.0", res.Proto)
+	assert.Equal(t, "collector-1,collector-1.example.com", string(body))
+
+	// without client certificate the handshake fails
+	anonymousConfig, err := ClientTLSConfig(filepath.Join(dir, "ca.pem"), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	anonymous := &http.Client{Transport: &http.Transport{TLSClientConfig: anonymousConfig}}
+	if res, err := anonymous.Get(server.URL); err == nil {
+		res.Body.Close()
+		t.Error("expected request without client certificate to fail")
+	}
+}
+
+func TestIdentityMatches(t *testing.T) {
+	identity := Identity{
+		CommonName: "collector-1",
+		DNSNames:   []string{"collector-1.example.com"},
+		URIs:       []string{"spiffe://example.com/collector-1"},
+	}
+	assert.True(t, identity.Matches("collector-1"))
+	assert.True(t, identity.Matches("collector-1.example.com"))
+	assert.True(t, identity.Matches("spiffe://example.com/collector-1"))
+	assert.False(t, identity.Matches("collector-2"))
+	assert.False(t, identity.Matches(""))
+}
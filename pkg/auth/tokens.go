@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const hashPrefix = "sha256:"
+
+type Scope string
+
+const (
+	// list and get collectors and configs
+	ScopeRead Scope = "read"
+	// add, update and remove configs
+	ScopeWrite Scope = "write"
+	// register collectors and fetch their configs with the token policy
+	ScopeEnroll Scope = "enroll"
+)
+
+var (
+	ErrLoadTokens   = errors.New("could not load tokens")
+	ErrTokenHash    = errors.New("token hash malformed, make sure it looks like sha256:[hex]")
+	ErrTokenScope   = errors.New("unknown token scope")
+	ErrTokenInvalid = errors.New("invalid token")
+
+	knownScopes = []Scope{ScopeRead, ScopeWrite, ScopeEnroll}
+)
+
+// Token is a static api token, only its hash is kept
+type Token struct {
+	Name   string  `yaml:"name"`
+	Hash   string  `yaml:"hash"`
+	Scopes []Scope `yaml:"scopes"`
+	sum    []byte
+}
+
+// Allows reports if the token was granted scope
+func (t Token) Allows(scope Scope) bool {
+	return slices.Contains(t.Scopes, scope)
+}
+
+// Tokens are the static api tokens read from a token file
+type Tokens struct {
+	tokens []Token
+}
+
+type tokenFile struct {
+	Tokens []Token `yaml:"tokens"`
+}
+
+// HashToken returns the hash of a token as stored in token files
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hashPrefix + hex.EncodeToString(sum[:])
+}
+
+// LoadTokens reads a yaml token file
+func LoadTokens(path string) (*Tokens, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Join(ErrLoadTokens, err)
+	}
+	return ParseTokens(raw)
+}
+
+// ParseTokens parses a yaml token file of the form
+//
+//	tokens:
+//	  - name: ci
+//	    hash: sha256:[hex]
+//	    scopes: [read, write]
+func ParseTokens(raw []byte) (*Tokens, error) {
+	var file tokenFile
+	if err := yaml.Unmarshal(raw, &file); err != nil {
+		return nil, errors.Join(ErrLoadTokens, err)
+	}
+	for i, token := range file.Tokens {
+		hexSum, ok := strings.CutPrefix(token.Hash, hashPrefix)
+		if !ok {
+			return nil, fmt.Errorf("%w of %v", ErrTokenHash, token.Name)
+		}
+		sum, err := hex.DecodeString(hexSum)
+		if err != nil || len(sum) != sha256.Size {
+			return nil, fmt.Errorf("%w of %v", ErrTokenHash, token.Name)
+		}
+		for _, scope := range token.Scopes {
+			if !slices.Contains(knownScopes, scope) {
+				return nil, fmt.Errorf("%w %v of %v", ErrTokenScope, scope, token.Name)
+			}
+		}
+		file.Tokens[i].sum = sum
+	}
+	return &Tokens{tokens: file.Tokens}, nil
+}
+
+// Authenticate returns the token matching the plain text token
+func (t *Tokens) Authenticate(token string) (Token, error) {
+	if token == "" {
+		return Token{}, ErrTokenInvalid
+	}
+	sum := sha256.Sum256([]byte(token))
+	for _, known := range t.tokens {
+		if subtle.ConstantTimeCompare(sum[:], known.sum) == 1 {
+			return known, nil
+		}
+	}
+	return Token{}, ErrTokenInvalid
+}
+
+// Len returns the number of known tokens
+func (t *Tokens) Len() int {
+	return len(t.tokens)
+}
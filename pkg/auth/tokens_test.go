@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr error
+	}{
+		{
+			name: "valid",
+			raw: fmt.Sprintf(
+				"tokens:\n  - name: ci\n    hash: %v\n    scopes: [read, write]\n",
+				HashToken("secret"),
+			),
+		},
+		{
+			name:    "plain text token",
+			raw:     "tokens:\n  - name: ci\n    hash: secret\n    scopes: [read]\n",
+			wantErr: ErrTokenHash,
+		},
+		{
+			name:    "short hash",
+			raw:     "tokens:\n  - name: ci\n    hash: sha256:abcd\n    scopes: [read]\n",
+			wantErr: ErrTokenHash,
+		},
+		{
+			name: "unknown scope",
+			raw: fmt.Sprintf(
+				"tokens:\n  - name: ci\n    hash: %v\n    scopes: [admin]\n",
+				HashToken("secret"),
+			),
+			wantErr: ErrTokenScope,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTokens([]byte(tt.raw))
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	tokens, err := ParseTokens([]byte(fmt.Sprintf(
+		"tokens:\n  - name: reader\n    hash: %v\n    scopes: [read]\n  - name: admin\n    hash: %v\n    scopes: [read, write]\n",
+		HashToken("read-secret"),
+		HashToken("admin-secret"),
+	)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := tokens.Authenticate("admin-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "admin", token.Name)
+	assert.True(t, token.Allows(ScopeWrite))
+
+	token, err = tokens.Authenticate("read-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "reader", token.Name)
+	assert.False(t, token.Allows(ScopeWrite))
+
+	_, err = tokens.Authenticate("unknown")
+	assert.ErrorIs(t, err, ErrTokenInvalid)
+	_, err = tokens.Authenticate("")
+	assert.ErrorIs(t, err, ErrTokenInvalid)
+}
@@ -1,6 +1,10 @@
 package collector
 
 import (
+	"encoding/json"
+	"sync"
+	"time"
+
 	"github.com/myLogic207/go-arcs/pkg/store"
 )
 
@@ -9,6 +13,62 @@ type Collector interface {
 	Name() string
 	GetHash() string
 	SetHash(string)
+	// fingerprint of the credential the collector registered with
+	Credential() string
+	SetCredential(string)
+	// Seen records a registration or poll of the collector from peer
+	Seen(peer string, err error)
+	Status() Status
+	// Override returns the override attached to the collector, if any
+	Override() (Override, bool)
+	// SetOverride attaches an override, nil removes it
+	SetOverride(*Override)
+}
+
+// Status is what is known about the last contact with a collector
+type Status struct {
+	LastSeen  time.Time
+	Peer      string
+	Hash      string
+	LastError string
+}
+
+type Health int
+
+const (
+	HealthUnknown Health = iota
+	HealthHealthy
+	HealthStale
+	HealthLost
+)
+
+func (h Health) String() string {
+	switch h {
+	case HealthHealthy:
+		return "healthy"
+	case HealthStale:
+		return "stale"
+	case HealthLost:
+		return "lost"
+	default:
+		return "unknown"
+	}
+}
+
+// Health derives the health from the time since the collector was last seen
+func (s Status) Health(now time.Time, staleAfter time.Duration, lostAfter time.Duration) Health {
+	if s.LastSeen.IsZero() {
+		return HealthUnknown
+	}
+	since := now.Sub(s.LastSeen)
+	switch {
+	case since >= lostAfter:
+		return HealthLost
+	case since >= staleAfter:
+		return HealthStale
+	default:
+		return HealthHealthy
+	}
 }
 
 type Store interface {
@@ -20,14 +80,20 @@ type collector struct {
 	name       string
 	attributes map[string]string
 	hash       string
+	credential string
+	lastSeen   time.Time
+	peer       string
+	lastError  string
+	override   *Override
+	mu         sync.RWMutex
 }
 
 func New(id string, name string, attributes map[string]string, hash string) Collector {
 	return &collector{
-		id,
-		name,
-		attributes,
-		hash,
+		id:         id,
+		name:       name,
+		attributes: attributes,
+		hash:       hash,
 	}
 }
 
@@ -44,9 +110,128 @@ func (c *collector) Attributes() map[string]string {
 }
 
 func (c *collector) SetHash(cfg string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.hash = store.Hash([]byte(cfg))
 }
 
 func (c *collector) GetHash() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.hash
 }
+
+func (c *collector) Credential() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.credential
+}
+
+func (c *collector) SetCredential(credential string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.credential = credential
+}
+
+func (c *collector) Seen(peer string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastSeen = time.Now()
+	c.peer = peer
+	c.lastError = ""
+	if err != nil {
+		c.lastError = err.Error()
+	}
+}
+
+func (c *collector) Status() Status {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return Status{
+		LastSeen:  c.lastSeen,
+		Peer:      c.peer,
+		Hash:      c.hash,
+		LastError: c.lastError,
+	}
+}
+
+func (c *collector) Override() (Override, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.override == nil {
+		return Override{}, false
+	}
+	return *c.override, true
+}
+
+func (c *collector) SetOverride(override *Override) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if override == nil {
+		c.override = nil
+		return
+	}
+	copied := *override
+	c.override = &copied
+}
+
+// persisted form of a collector
+type collectorRecord struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Hash       string            `json:"hash,omitempty"`
+	Credential string            `json:"credential,omitempty"`
+	LastSeen   time.Time         `json:"last_seen,omitzero"`
+	Peer       string            `json:"peer,omitempty"`
+	LastError  string            `json:"last_error,omitempty"`
+	Override   *overrideRecord   `json:"override,omitempty"`
+}
+
+// Codec serializes collectors for persistent stores
+type Codec struct{}
+
+func (Codec) Marshal(c Collector) ([]byte, error) {
+	status := c.Status()
+	var override *overrideRecord
+	if o, ok := c.Override(); ok {
+		override = &overrideRecord{Source: o.Source, Mode: o.Mode.String(), Expires: o.Expires}
+	}
+	return json.Marshal(collectorRecord{
+		ID:         c.ID(),
+		Name:       c.Name(),
+		Attributes: c.Attributes(),
+		Hash:       status.Hash,
+		Credential: c.Credential(),
+		LastSeen:   status.LastSeen,
+		Peer:       status.Peer,
+		LastError:  status.LastError,
+		Override:   override,
+	})
+}
+
+func (Codec) Unmarshal(data []byte) (Collector, error) {
+	var record collectorRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	var override *Override
+	if record.Override != nil {
+		mode, err := ParseOverrideMode(record.Override.Mode)
+		if err != nil {
+			return nil, err
+		}
+		override = &Override{Source: record.Override.Source, Mode: mode, Expires: record.Override.Expires}
+	}
+	return &collector{
+		id:         record.ID,
+		name:       record.Name,
+		attributes: record.Attributes,
+		hash:       record.Hash,
+		credential: record.Credential,
+		lastSeen:   record.LastSeen,
+		peer:       record.Peer,
+		lastError:  record.LastError,
+		override:   override,
+	}, nil
+}
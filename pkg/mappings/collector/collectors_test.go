@@ -0,0 +1,114 @@
+package collector
+
+import (
+	"cmp"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealth(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		lastSeen time.Time
+		want     Health
+	}{
+		{name: "never seen", want: HealthUnknown},
+		{name: "healthy", lastSeen: now.Add(-time.Minute), want: HealthHealthy},
+		{name: "stale", lastSeen: now.Add(-5 * time.Minute), want: HealthStale},
+		{name: "lost", lastSeen: now.Add(-time.Hour), want: HealthLost},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := Status{LastSeen: tt.lastSeen}
+			assert.Equal(t, tt.want, status.Health(now, 3*time.Minute, 10*time.Minute))
+		})
+	}
+}
+
+func TestCodec(t *testing.T) {
+	col := New("id", "name", map[string]string{"env": "prod"}, "")
+	col.SetHash("content")
+	col.SetCredential("cert:abc")
+	col.Seen("10.0.0.1:4242", errors.New("fetch failed"))
+
+	data, err := Codec{}.Marshal(col)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Codec{}.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, col.ID(), decoded.ID())
+	assert.Equal(t, col.Attributes(), decoded.Attributes())
+	assert.Equal(t, col.Credential(), decoded.Credential())
+	want, got := col.Status(), decoded.Status()
+	assert.True(t, want.LastSeen.Equal(got.LastSeen))
+	want.LastSeen, got.LastSeen = time.Time{}, time.Time{}
+	assert.Equal(t, want, got)
+	assert.Equal(t, "fetch failed", got.LastError)
+
+	col.Seen("10.0.0.1:4242", nil)
+	assert.Empty(t, col.Status().LastError)
+}
+
+func TestOverride(t *testing.T) {
+	col := New("id", "name", nil, "")
+	_, ok := col.Override()
+	assert.False(t, ok)
+
+	expires := time.Now().Add(time.Hour).UTC()
+	col.SetOverride(&Override{Source: "file://test.alloy", Mode: OverrideAppend, Expires: expires})
+
+	data, err := Codec{}.Marshal(col)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Codec{}.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	override, ok := decoded.Override()
+	assert.True(t, ok)
+	assert.Equal(t, "file://test.alloy", override.Source)
+	assert.Equal(t, OverrideAppend, override.Mode)
+	assert.True(t, expires.Equal(override.Expires))
+	assert.False(t, override.Expired(time.Now()))
+	assert.True(t, override.Expired(expires))
+
+	decoded.SetOverride(nil)
+	_, ok = decoded.Override()
+	assert.False(t, ok)
+	assert.False(t, Override{}.Expired(time.Now()), "overrides without expiry never expire")
+}
+
+func TestParseOverrideMode(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    OverrideMode
+		wantErr bool
+	}{
+		{raw: "", want: OverrideReplace},
+		{raw: "replace", want: OverrideReplace},
+		{raw: "append", want: OverrideAppend},
+		{raw: "merge", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			mode, err := ParseOverrideMode(tt.raw)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrOverrideMode)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, mode)
+			assert.Equal(t, cmp.Or(tt.raw, "replace"), mode.String())
+		})
+	}
+}
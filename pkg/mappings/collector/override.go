@@ -0,0 +1,58 @@
+package collector
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrOverrideMode = errors.New("unknown override mode, use 'replace' or 'append'")
+
+// OverrideMode defines how an override is combined with the configs matching a collector
+type OverrideMode uint8
+
+const (
+	// delivers only the override
+	OverrideReplace OverrideMode = iota
+	// delivers the override after the matching configs
+	OverrideAppend
+)
+
+func ParseOverrideMode(raw string) (OverrideMode, error) {
+	switch raw {
+	case "", "replace":
+		return OverrideReplace, nil
+	case "append":
+		return OverrideAppend, nil
+	default:
+		return OverrideReplace, ErrOverrideMode
+	}
+}
+
+func (m OverrideMode) String() string {
+	switch m {
+	case OverrideAppend:
+		return "append"
+	default:
+		return "replace"
+	}
+}
+
+// Override is a source delivered to a single collector, e.g. to test a change
+type Override struct {
+	Source string
+	Mode   OverrideMode
+	// zero if the override does not expire
+	Expires time.Time
+}
+
+// Expired reports whether the override expired at now
+func (o Override) Expired(now time.Time) bool {
+	return !o.Expires.IsZero() && !now.Before(o.Expires)
+}
+
+// persisted form of an override
+type overrideRecord struct {
+	Source  string    `json:"source"`
+	Mode    string    `json:"mode,omitempty"`
+	Expires time.Time `json:"expires,omitzero"`
+}
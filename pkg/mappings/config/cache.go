@@ -0,0 +1,303 @@
+package config
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/myLogic207/go-arcs/pkg/metrics"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// upper limit for a single fetch shared by concurrent requests
+	fetchTimeout = 30 * time.Second
+	// responses kept at most, collectors choose the values of forwarded headers
+	maxCacheEntries = 1024
+)
+
+var (
+	ErrHTTPStatus = errors.New("unexpected response status")
+
+	// headers of the collector request that are not forwarded to sources,
+	// credentials of collectors are meant for this server only
+	droppedHeaders = []string{
+		"Accept-Encoding",
+		"Authorization",
+		"Content-Length",
+		"Content-Type",
+		"Cookie",
+		"Proxy-Authorization",
+	}
+
+	defaultCache = newHTTPCache(http.DefaultClient)
+)
+
+type cacheEntry struct {
+	content      []byte
+	etag         string
+	lastModified string
+	expires      time.Time
+	// last time the entry was served or stored, guarded by the cache lock
+	used time.Time
+}
+
+func (e *cacheEntry) revision() string {
+	return cmp.Or(e.etag, e.lastModified)
+}
+
+// httpCache caches remote content and revalidates it with the origin
+// using ETag and Last-Modified, concurrent fetches of a url are deduplicated.
+// Responses are cached per url and the forwarded headers named in their Vary header.
+type httpCache struct {
+	client *http.Client
+	// cached responses by key
+	entries map[string]*cacheEntry
+	// header names the last response of a url varies on
+	vary  map[string][]string
+	mu    sync.Mutex
+	group singleflight.Group
+	now   func() time.Time
+	// entries kept before the least recently used one is evicted
+	limit int
+}
+
+func newHTTPCache(client *http.Client) *httpCache {
+	return &httpCache{
+		client:  client,
+		entries: make(map[string]*cacheEntry),
+		vary:    make(map[string][]string),
+		now:     time.Now,
+		limit:   maxCacheEntries,
+	}
+}
+
+// stores entry under key and evicts the least recently used entries beyond the limit,
+// must be called while holding the lock
+func (c *httpCache) store(key string, entry *cacheEntry) {
+	entry.used = c.now()
+	c.entries[key] = entry
+	for len(c.entries) > c.limit {
+		var oldest string
+		for key, entry := range c.entries {
+			if oldest == "" || entry.used.Before(c.entries[oldest].used) {
+				oldest = key
+			}
+		}
+		delete(c.entries, oldest)
+	}
+}
+
+// key of the response to url for the headers it varies on, must be called while holding the lock
+func (c *httpCache) key(url string, headers http.Header) string {
+	key := url
+	for _, name := range c.vary[url] {
+		key += fmt.Sprintf("\n%v: %q", name, headers.Values(name))
+	}
+	return key
+}
+
+// headers of a collector request that are forwarded to sources
+func forwarded(headers http.Header) http.Header {
+	if headers == nil {
+		return http.Header{}
+	}
+	headers = headers.Clone()
+	for _, header := range droppedHeaders {
+		headers.Del(header)
+	}
+	return headers
+}
+
+// header names of the Vary header, nil if the response must not be cached
+func parseVary(header http.Header) ([]string, bool) {
+	var names []string
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "*" {
+				return nil, false
+			}
+			if name != "" && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names, true
+}
+
+// get returns the content of url and its revision (ETag or Last-Modified), served from cache while fresh.
+// Content is fresh for ttl if set, otherwise as long as Cache-Control or Expires allow.
+// If the origin fails, stale content is served if available.
+// Requests are passed to sign, if set, right before they are sent.
+func (c *httpCache) get(
+	ctx context.Context,
+	url string,
+	headers http.Header,
+	ttl time.Duration,
+	sign func(*http.Request),
+) ([]byte, string, error) {
+	headers = forwarded(headers)
+	c.mu.Lock()
+	key := c.key(url, headers)
+	entry := c.entries[key]
+	if entry != nil {
+		entry.used = c.now()
+	}
+	c.mu.Unlock()
+	if entry != nil && c.now().Before(entry.expires) {
+		metrics.ObserveCache(metrics.CacheHit)
+		return entry.content, entry.revision(), nil
+	}
+
+	result := c.group.DoChan(key, func() (any, error) {
+		// shared by all waiting requests, must not be canceled by the first one
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fetchTimeout)
+		defer cancel()
+		return c.fetch(fetchCtx, url, headers, ttl, sign, entry)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, "", ctx.Err()
+	case res := <-result:
+		if res.Err != nil {
+			return nil, "", res.Err
+		}
+		entry := res.Val.(*cacheEntry)
+		return entry.content, entry.revision(), nil
+	}
+}
+
+func (c *httpCache) fetch(
+	ctx context.Context,
+	url string,
+	headers http.Header,
+	ttl time.Duration,
+	sign func(*http.Request),
+	cached *cacheEntry,
+) (*cacheEntry, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = headers.Clone()
+	if cached != nil {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+	if sign != nil {
+		sign(req)
+	}
+
+	content, res, err := c.do(req)
+	if err != nil {
+		if cached != nil {
+			slog.Warn("Serving stale content", "url", url, "error", err)
+			metrics.ObserveCache(metrics.CacheStale)
+			return cached, nil
+		}
+		metrics.ObserveCache(metrics.CacheMiss)
+		return nil, err
+	}
+
+	freshness, store := freshness(res.Header, ttl, c.now())
+	vary, cacheable := parseVary(res.Header)
+	entry := &cacheEntry{
+		content:      content,
+		etag:         res.Header.Get("ETag"),
+		lastModified: res.Header.Get("Last-Modified"),
+		expires:      c.now().Add(freshness),
+	}
+	result := metrics.CacheMiss
+	if res.StatusCode == http.StatusNotModified && cached != nil {
+		result = metrics.CacheRevalidated
+		entry.content = cached.content
+		entry.etag = cmp.Or(entry.etag, cached.etag)
+		entry.lastModified = cmp.Or(entry.lastModified, cached.lastModified)
+	}
+	c.mu.Lock()
+	if res.StatusCode != http.StatusNotModified || len(vary) > 0 {
+		c.vary[url] = vary
+	}
+	key := c.key(url, headers)
+	if store && cacheable {
+		c.store(key, entry)
+	} else {
+		delete(c.entries, key)
+	}
+	c.mu.Unlock()
+	metrics.ObserveCache(result)
+	return entry, nil
+}
+
+func (c *httpCache) do(req *http.Request) ([]byte, *http.Response, error) {
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotModified {
+		return nil, res, nil
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, nil, fmt.Errorf("%w %v from %v", ErrHTTPStatus, res.Status, req.URL)
+	}
+	content, err := ioRead(res.Body)
+	if err != nil {
+		return nil, nil, errors.Join(ErrGetContent, err)
+	}
+	return content, res, nil
+}
+
+// reports how long a response is fresh and if it may be stored at all,
+// max-age takes precedence over Expires
+func freshness(header http.Header, ttl time.Duration, now time.Time) (time.Duration, bool) {
+	var maxAge time.Duration
+	noCache, hasMaxAge := false, false
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(strings.ToLower(directive)), "=")
+		switch name {
+		case "no-store":
+			return 0, false
+		case "no-cache":
+			noCache = true
+		case "max-age":
+			hasMaxAge = true
+			if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+				maxAge = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	if !hasMaxAge && header.Get("Expires") != "" {
+		// invalid dates like "0" mean already expired
+		if expires, err := http.ParseTime(header.Get("Expires")); err == nil {
+			if date, err := http.ParseTime(header.Get("Date")); err == nil {
+				now = date
+			}
+			maxAge = max(expires.Sub(now), 0)
+		}
+	}
+	switch {
+	case ttl > 0:
+		return ttl, true
+	case noCache:
+		// store, but revalidate every time
+		return 0, true
+	default:
+		return maxAge, true
+	}
+}
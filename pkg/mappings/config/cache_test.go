@@ -0,0 +1,214 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CacheRevalidate(t *testing.T) {
+	var requests, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("content"))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	cache := newHTTPCache(server.Client())
+	for range 3 {
+		content, _, err := cache.get(ctx, server.URL, nil, 0, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "content", string(content))
+	}
+	assert.Equal(t, int32(3), requests.Load())
+	assert.Equal(t, int32(2), notModified.Load())
+}
+
+func Test_CacheFreshness(t *testing.T) {
+	tests := []struct {
+		name         string
+		cacheControl string
+		expires      string
+		ttl          time.Duration
+		wantRequests int32
+	}{
+		{name: "max-age", cacheControl: "public, max-age=60", wantRequests: 1},
+		{name: "ttl", ttl: time.Minute, wantRequests: 1},
+		{name: "ttl overrides no-cache", cacheControl: "no-cache", ttl: time.Minute, wantRequests: 1},
+		{name: "no-store", cacheControl: "no-store, max-age=60", wantRequests: 3},
+		{name: "expired", cacheControl: "max-age=1", wantRequests: 2},
+		{name: "expires", expires: time.Now().Add(time.Hour).Format(http.TimeFormat), wantRequests: 1},
+		{name: "expires in the past", expires: "0", wantRequests: 3},
+		{name: "max-age overrides expires", cacheControl: "max-age=0", expires: time.Now().Add(time.Hour).Format(http.TimeFormat), wantRequests: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.Header().Set("Cache-Control", tt.cacheControl)
+				if tt.expires != "" {
+					w.Header().Set("Expires", tt.expires)
+				}
+				w.Write([]byte("content"))
+			}))
+			defer server.Close()
+
+			now := time.Now()
+			cache := newHTTPCache(server.Client())
+			cache.now = func() time.Time { return now }
+			for i := range 3 {
+				if tt.name == "expired" && i == 2 {
+					now = now.Add(2 * time.Second)
+				}
+				if _, _, err := cache.get(context.Background(), server.URL, nil, tt.ttl, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+			assert.Equal(t, tt.wantRequests, requests.Load())
+		})
+	}
+}
+
+func Test_CacheStaleIfError(t *testing.T) {
+	var fail atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("content"))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	cache := newHTTPCache(server.Client())
+	fail.Store(true)
+	_, _, err := cache.get(ctx, server.URL, nil, 0, nil)
+	assert.ErrorIs(t, err, ErrHTTPStatus)
+
+	fail.Store(false)
+	if _, _, err := cache.get(ctx, server.URL, nil, 0, nil); err != nil {
+		t.Fatal(err)
+	}
+	fail.Store(true)
+	content, _, err := cache.get(ctx, server.URL, nil, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "content", string(content))
+}
+
+func Test_CacheDeduplicate(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		w.Write([]byte("content"))
+	}))
+	defer server.Close()
+
+	cache := newHTTPCache(server.Client())
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			content, _, err := cache.get(context.Background(), server.URL, nil, 0, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, "content", string(content))
+		}()
+	}
+	// let all requests join the running fetch
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func Test_CacheVary(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		assert.Empty(t, r.Header.Get("Authorization"), "credentials of collectors are not forwarded")
+		assert.Empty(t, r.Header.Get("Cookie"))
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "X-Tenant")
+		w.Write([]byte("tenant " + r.Header.Get("X-Tenant")))
+	}))
+	defer server.Close()
+
+	cache := newHTTPCache(server.Client())
+	get := func(tenant string) string {
+		headers := http.Header{}
+		headers.Set("X-Tenant", tenant)
+		headers.Set("Authorization", "Bearer secret")
+		headers.Set("Cookie", "session=secret")
+		content, _, err := cache.get(context.Background(), server.URL, headers, 0, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(content)
+	}
+	assert.Equal(t, "tenant a", get("a"))
+	assert.Equal(t, "tenant b", get("b"))
+	assert.Equal(t, "tenant a", get("a"))
+	assert.Equal(t, "tenant b", get("b"))
+	assert.Equal(t, int32(2), requests.Load(), "every variant is fetched once")
+}
+
+func Test_CacheEvict(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "X-Tenant")
+		w.Write([]byte("tenant " + r.Header.Get("X-Tenant")))
+	}))
+	defer server.Close()
+
+	cache := newHTTPCache(server.Client())
+	cache.limit = 2
+	// every call is a moment later, so entries are ordered by use
+	now := time.Now()
+	cache.now = func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	}
+	get := func(tenant string) {
+		headers := http.Header{}
+		headers.Set("X-Tenant", tenant)
+		if _, _, err := cache.get(context.Background(), server.URL, headers, 0, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	get("a")
+	get("b")
+	get("a")
+	// evicts b, which was used least recently
+	get("c")
+	assert.Len(t, cache.entries, 2)
+	assert.Equal(t, int32(3), requests.Load())
+	get("a")
+	assert.Equal(t, int32(3), requests.Load(), "a is still cached")
+	get("b")
+	assert.Equal(t, int32(4), requests.Load(), "b was evicted")
+	assert.Len(t, cache.entries, 2)
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/grafana/alloy/syntax/ast"
+)
+
+var ErrDuplicateComponent = errors.New("duplicate component")
+
+// Fragment is the content of a single config
+type Fragment struct {
+	Source  string
+	Content string
+}
+
+// Sort orders configs by priority, lower first, then by source
+func Sort(configs []Config) {
+	slices.SortStableFunc(configs, func(a Config, b Config) int {
+		return cmp.Or(
+			cmp.Compare(a.Priority(), b.Priority()),
+			strings.Compare(a.Source(), b.Source()),
+		)
+	})
+}
+
+// Compose joins fragments into a single alloy config separated by newlines,
+// it fails if two fragments define the same top level block
+func Compose(fragments []Fragment) (string, error) {
+	if err := checkDuplicates(fragments); err != nil {
+		return "", err
+	}
+	parts := make([]string, len(fragments))
+	for i, fragment := range fragments {
+		parts[i] = strings.TrimSpace(fragment.Content)
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// fails if two top level blocks share the same name and label
+func checkDuplicates(fragments []Fragment) error {
+	seen := make(map[string]string)
+	var errs error
+	for _, fragment := range fragments {
+		file, err := parseFile(fragment.Source, fragment.Content)
+		if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+		for _, stmt := range file.Body {
+			block, ok := stmt.(*ast.BlockStmt)
+			if !ok {
+				continue
+			}
+			key := blockKey(block)
+			location := fmt.Sprintf("%v:%v", fragment.Source, block.NamePos.Position().Line)
+			if first, ok := seen[key]; ok {
+				errs = errors.Join(errs, fmt.Errorf("%w %v in %v, first defined in %v", ErrDuplicateComponent, key, location, first))
+				continue
+			}
+			seen[key] = location
+		}
+	}
+	return errs
+}
+
+// name and label of a block like 'prometheus.scrape "default"'
+func blockKey(block *ast.BlockStmt) string {
+	name := strings.Join(block.Name, ".")
+	if block.Label == "" {
+		return name
+	}
+	return fmt.Sprintf("%v %q", name, block.Label)
+}
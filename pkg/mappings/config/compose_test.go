@@ -0,0 +1,95 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Sort(t *testing.T) {
+	var configs []Config
+	for _, mapping := range []Mapping{
+		{Source: "file://b", Priority: 1},
+		{Source: "file://c"},
+		{Source: "file://a", Priority: 1},
+		{Source: "file://d", Priority: -1},
+	} {
+		conf, err := mapping.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		configs = append(configs, conf)
+	}
+
+	Sort(configs)
+	sources := make([]string, len(configs))
+	for i, conf := range configs {
+		sources[i] = conf.Source()
+	}
+	assert.Equal(t, []string{"file://d", "file://c", "file://a", "file://b"}, sources)
+}
+
+func Test_Compose(t *testing.T) {
+	scrape := Fragment{
+		Source: "file://scrape",
+		Content: `
+// comment with prometheus.scrape "default" {
+prometheus.scrape "default" {
+	targets    = [{"__address__" = "localhost:12345"}]
+	forward_to = [prometheus.remote_write.default.receiver]
+}
+`,
+	}
+	write := Fragment{
+		Source: "file://write",
+		Content: `prometheus.remote_write "default" {
+	endpoint {
+		url = "http://prometheus:9090/api/v1/write"
+	}
+}`,
+	}
+	duplicate := Fragment{
+		Source: "file://duplicate",
+		Content: `
+prometheus.scrape "other" { }
+
+prometheus.scrape "default" {
+	targets = []
+}`,
+	}
+
+	got, err := Compose([]Fragment{scrape, write})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, got, "}\n\nprometheus.remote_write \"default\" {")
+	assert.NoError(t, parse("composed", got), "composed configs parse")
+
+	_, err = Compose([]Fragment{scrape, write, duplicate})
+	assert.ErrorIs(t, err, ErrDuplicateComponent)
+	assert.ErrorContains(t, err, `prometheus.scrape "default" in file://duplicate:4, first defined in file://scrape:3`)
+
+	// blocks in strings and comments or nested in other blocks are no components
+	quoted := Fragment{
+		Source: "file://quoted",
+		Content: `
+/* prometheus.scrape "default" { } */
+local.file "rules" {
+	filename = "prometheus.scrape \"default\" {}"
+}
+discovery.relabel "default" {
+	targets = []
+	rule {
+		replacement = ` + "`prometheus.remote_write \"default\" {`" + `
+	}
+}`,
+	}
+	got, err = Compose([]Fragment{scrape, write, quoted})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, parse("composed", got))
+
+	_, err = Compose([]Fragment{write, {Source: "file://broken", Content: "prometheus.scrape {"}})
+	assert.ErrorIs(t, err, ErrParseContent)
+}
@@ -1,13 +1,20 @@
 package config
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"slices"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/myLogic207/go-arcs/pkg/metrics"
 	"github.com/myLogic207/go-arcs/pkg/store"
 )
 
@@ -22,6 +29,12 @@ var (
 		"file",
 		"http",
 		"https",
+		"git",
+		"git+ssh",
+		"git+http",
+		"git+https",
+		"git+file",
+		"s3",
 	}
 )
 
@@ -29,8 +42,45 @@ type ContentHandler func(context.Context, string, ...any) ([]byte, error)
 
 type Config interface {
 	store.Object
+	store.Matcher
 	Content(context.Context, ...any) (string, error)
 	Source() string
+	Match() store.MatchMode
+	Selector() string
+	// content is rendered as go template
+	Template() bool
+	// configs are composed by priority, lower first
+	Priority() int
+	// remote content is cached for ttl, if set
+	TTL() time.Duration
+	// the server is not ready while the source of a required config is unreachable
+	Required() bool
+	// Check fetches the content without rendering it to see if the source is reachable
+	Check(context.Context) error
+	// ParseError of the latest content, the last content that parsed is served meanwhile.
+	// Templates parse per collector, their errors are only returned by Content.
+	ParseError() error
+	// Fallback defines what is served if the source fails
+	Fallback() FallbackMode
+	// Rollout reports the state of staging changed content to canaries
+	Rollout() RolloutStatus
+	// Canary reports whether the collector receives changed content first
+	Canary(id string) bool
+	// Promote delivers the content under rollout to all collectors
+	Promote() error
+	// Rollback keeps delivering the stable content until the source changes again
+	Rollback() error
+	// Hold records why the due rollout of the content with hash is not promoted,
+	// reports whether the reason changed
+	Hold(hash string, reason string) bool
+	// Versions of the content that parsed, newest first
+	Versions() []Version
+	// Pin delivers the version with hash to all collectors instead of the source
+	Pin(hash string) error
+	// Unpin delivers the source again, reports whether a version was pinned
+	Unpin() bool
+	// Pinned returns the pinned version, if any
+	Pinned() (Version, bool)
 }
 
 type Store interface {
@@ -42,9 +92,36 @@ type config struct {
 	protocol   string
 	path       string
 	attributes map[string]string
+	match      store.MatchMode
+	selector   *store.Selector
+	template   bool
+	priority   int
+	ttl        time.Duration
+	required   bool
+	fallback   FallbackMode
+	rollout    rolloutPolicy
+	runtime    bool
+	git        gitSource
+	s3         s3Source
+	mu         sync.Mutex
+	// last raw content that parsed
+	good     []byte
+	parseErr error
+	// hash of the latest rejected content
+	rejected string
+	// content delivered to all collectors and to canaries during a rollout
+	stable       []byte
+	canary       []byte
+	rolloutStart time.Time
+	// hash of the last rolled back content
+	rolledBack string
+	// why the due rollout is not promoted
+	held    string
+	history []Version
+	pinned  *Version
 }
 
-func New(source string, attributes map[string]string) (Config, error) {
+func New(source string, attributes map[string]string, options ...Option) (Config, error) {
 	id := store.Hash([]byte(source))
 	protocol, path, found := strings.Cut(source, ProtoDelimiter)
 	if !found {
@@ -55,12 +132,36 @@ func New(source string, attributes map[string]string) (Config, error) {
 		return nil, ErrProtoUnknown
 	}
 
-	return &config{
-		id,
-		protocol,
-		path,
-		attributes,
-	}, nil
+	var git gitSource
+	if protocol == "git" || strings.HasPrefix(protocol, gitProtoPrefix) {
+		var err error
+		if git, err = parseGitSource(protocol, path); err != nil {
+			return nil, err
+		}
+	}
+
+	var s3 s3Source
+	if protocol == "s3" {
+		var err error
+		if s3, err = parseS3Source(path); err != nil {
+			return nil, err
+		}
+	}
+
+	conf := &config{
+		id:         id,
+		protocol:   protocol,
+		path:       path,
+		attributes: attributes,
+		git:        git,
+		s3:         s3,
+	}
+	for _, option := range options {
+		if err := option(conf); err != nil {
+			return nil, err
+		}
+	}
+	return conf, nil
 }
 
 func (c *config) ID() string {
@@ -72,31 +173,283 @@ func (c *config) Attributes() map[string]string {
 	return c.attributes
 }
 
+func (c *config) Match() store.MatchMode {
+	return c.match
+}
+
+func (c *config) Selector() string {
+	return c.selector.String()
+}
+
+// attributes and selector both have to match,
+// without attributes only the selector is evaluated
+func (c *config) Matches(attributes map[string]string) bool {
+	if c.selector.Empty() {
+		return store.Match(c.match, c.attributes, attributes)
+	}
+	if len(c.attributes) > 0 && !store.Match(c.match, c.attributes, attributes) {
+		return false
+	}
+	return c.selector.Matches(attributes)
+}
+
+// Content fetches the config content, options may contain the
+// http.Header to forward, the TemplateData to render templates with
+// and a *ContentState to report a fallback to the last known good content or a changed rollout
 func (c *config) Content(ctx context.Context, options ...any) (string, error) {
-	var contentHandler func(context.Context, string) ([]byte, error)
+	var headers http.Header
+	var data TemplateData
+	var state *ContentState
+	for _, option := range options {
+		switch option := option.(type) {
+		case http.Header:
+			headers = option
+		case TemplateData:
+			data = option
+		case *ContentState:
+			state = option
+		}
+	}
+
+	if pinned, ok := c.Pinned(); ok {
+		content, err := c.render(pinned.Content, data)
+		if err != nil {
+			return "", err
+		}
+		return content, ctx.Err()
+	}
+	raw, content, err := c.latest(ctx, headers, data, state)
+	if err != nil {
+		return "", err
+	}
+	stable, ok, changed := c.roll(raw, data.ID)
+	if changed && state != nil {
+		state.RolloutChanged = true
+	}
+	// collectors outside of a rollout keep the stable content
+	if ok {
+		if content, err = c.render(stable, data); err != nil {
+			return "", err
+		}
+	}
+	return content, ctx.Err()
+}
+
+// latest returns the newest raw content that parsed and its rendering,
+// falling back to the last known good content if the source fails
+func (c *config) latest(
+	ctx context.Context,
+	headers http.Header,
+	data TemplateData,
+	state *ContentState,
+) ([]byte, string, error) {
+	raw, revision, err := c.fetch(ctx, headers)
+	if err != nil {
+		good := c.lastGood()
+		if c.fallback == FallbackFail || good == nil || ctx.Err() != nil {
+			return nil, "", err
+		}
+		slog.Warn("Serving last known good content", "source", c.Source(), "error", err)
+		metrics.ObserveFallback(c.protocol)
+		if state != nil {
+			state.Stale = true
+			state.Err = err
+		}
+		content, err := c.render(good, data)
+		return good, content, err
+	}
+	if !c.template {
+		if err := parse(c.Source(), string(raw)); err != nil {
+			good := c.reject(raw, err)
+			if good == nil {
+				return nil, "", err
+			}
+			return good, string(good), nil
+		}
+		c.accept(raw, revision)
+		return raw, string(raw), nil
+	}
+
+	// templates parse or fail per collector, so only renderings that parse
+	// change the state shared by all collectors
+	content, err := c.render(raw, data)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := parse(c.Source(), content); err != nil {
+		good := c.lastGood()
+		if good == nil || bytes.Equal(good, raw) {
+			return nil, "", err
+		}
+		slog.Warn("Serving last known good template", "source", c.Source(), "collector", data.ID, "error", err)
+		content, err := c.render(good, data)
+		return good, content, err
+	}
+	c.accept(raw, revision)
+	return raw, content, nil
+}
+
+// renders templates, other content is returned as is
+func (c *config) render(raw []byte, data TemplateData) (string, error) {
+	if !c.template {
+		return string(raw), nil
+	}
+	return render(c.Source(), string(raw), data)
+}
+
+func (c *config) Check(ctx context.Context) error {
+	_, _, err := c.fetch(ctx, nil)
+	return err
+}
+
+// fetches the raw content and its revision from the source
+func (c *config) fetch(ctx context.Context, headers http.Header) ([]byte, string, error) {
+	var contentHandler func(context.Context, string) ([]byte, string, error)
 	switch c.protocol {
 	case "file":
-		contentHandler = fileHandler
+		contentHandler = func(ctx context.Context, s string) ([]byte, string, error) {
+			content, err := fileHandler(ctx, s)
+			if err != nil {
+				return nil, "", err
+			}
+			return content, fileRevision(s), nil
+		}
 	// case FTPProto:
 	// 	return ftpHandler
 	case "https":
 		fallthrough
 	case "http":
-		contentHandler = func(ctx context.Context, s string) ([]byte, error) {
+		contentHandler = func(ctx context.Context, s string) ([]byte, string, error) {
 			url := fmt.Sprintf("%v%v%v", c.protocol, ProtoDelimiter, c.path)
-			headers := options[0].(http.Header)
-			return httpHandler(ctx, url, headers)
+			return defaultCache.get(ctx, url, headers, c.ttl, nil)
+		}
+	case "git", "git+ssh", "git+http", "git+https", "git+file":
+		contentHandler = func(ctx context.Context, s string) ([]byte, string, error) {
+			return gitHandler(ctx, c.git, cmp.Or(c.ttl, GitFetchInterval))
+		}
+	case "s3":
+		contentHandler = func(ctx context.Context, s string) ([]byte, string, error) {
+			return s3Handler(ctx, c.s3, c.ttl)
 		}
 	default:
-		return "", ErrProtoUnknown
+		return nil, "", ErrProtoUnknown
 	}
-	content, err := contentHandler(ctx, c.path)
+	start := time.Now()
+	content, revision, err := contentHandler(ctx, c.path)
+	metrics.ObserveFetch(c.protocol, time.Since(start), err)
 	if err != nil {
-		return "", errors.Join(ErrGetContent, err)
+		return nil, "", errors.Join(ErrGetContent, err)
 	}
-	return string(content), ctx.Err()
+	return content, revision, nil
+}
+
+func (c *config) Template() bool {
+	return c.template
+}
+
+func (c *config) Priority() int {
+	return c.priority
+}
+
+func (c *config) TTL() time.Duration {
+	return c.ttl
+}
+
+func (c *config) Required() bool {
+	return c.required
+}
+
+func (c *config) Fallback() FallbackMode {
+	return c.fallback
 }
 
 func (c *config) Source() string {
 	return strings.Join([]string{string(c.protocol), c.path}, ProtoDelimiter)
 }
+
+func (c *config) mapping() Mapping {
+	m := Mapping{
+		Source:     c.Source(),
+		Attributes: c.attributes,
+		Selector:   c.selector.String(),
+		Template:   c.template,
+		Priority:   c.priority,
+		Required:   c.required,
+	}
+	if c.fallback != FallbackStale {
+		m.Fallback = c.fallback.String()
+	}
+	m.Rollout = c.rollout.serialize()
+	m.RolloutState = c.rolloutState()
+	if pinned, ok := c.Pinned(); ok {
+		m.Pinned = &pinned
+	}
+	m.Runtime = c.runtime
+	if c.ttl > 0 {
+		m.TTL = c.ttl.String()
+	}
+	if c.match != store.MatchAll {
+		m.Match = c.match.String()
+	}
+	return m
+}
+
+// Inherit carries the state learned from the content of old over to conf,
+// a config rebuilt for the same source, so changing its mapping loses nothing
+func Inherit(conf Config, old Config) {
+	c, ok := conf.(*config)
+	if !ok {
+		return
+	}
+	o, ok := old.(*config)
+	if !ok || o == c || o.id != c.id {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.good = o.good
+	c.parseErr = o.parseErr
+	c.rejected = o.rejected
+	c.stable = o.stable
+	c.canary = o.canary
+	c.rolloutStart = o.rolloutStart
+	c.rolledBack = o.rolledBack
+	c.held = o.held
+	c.history = slices.Clone(o.history)
+	c.pinned = o.pinned
+}
+
+// ToMapping returns the serialized form of a config
+func ToMapping(c Config) Mapping {
+	if conf, ok := c.(*config); ok {
+		return conf.mapping()
+	}
+	return Mapping{
+		Source:     c.Source(),
+		Attributes: c.Attributes(),
+		Match:      c.Match().String(),
+		Selector:   c.Selector(),
+		Template:   c.Template(),
+		Priority:   c.Priority(),
+		TTL:        c.TTL().String(),
+		Required:   c.Required(),
+		Fallback:   c.Fallback().String(),
+	}
+}
+
+// Codec serializes configs for persistent stores
+type Codec struct{}
+
+func (Codec) Marshal(c Config) ([]byte, error) {
+	return json.Marshal(ToMapping(c))
+}
+
+func (Codec) Unmarshal(data []byte) (Config, error) {
+	var m Mapping
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m.Build()
+}
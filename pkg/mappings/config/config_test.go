@@ -0,0 +1,87 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ContentTemplate(t *testing.T) {
+	data := TemplateData{
+		ID:                "collector-1",
+		Name:              "alloy",
+		Attributes:        map[string]string{"env": "prod"},
+		RequestAttributes: map[string]string{"region": "eu"},
+	}
+	tests := []struct {
+		name     string
+		content  string
+		template bool
+		want     string
+		wantErr  error
+	}{
+		{
+			name:     "raw content",
+			content:  `label = "{{ .ID }}"`,
+			template: false,
+			want:     `label = "{{ .ID }}"`,
+		},
+		{
+			name:     "render attributes",
+			content:  `label = {{ quote .ID }} // {{ .Name }} {{ .Attributes.env }} {{ upper .RequestAttributes.region }}`,
+			template: true,
+			want:     `label = "collector-1" // alloy prod EU`,
+		},
+		{
+			name:     "fail missing attribute",
+			content:  `team = {{ .Attributes.team }}`,
+			template: true,
+			wantErr:  ErrRenderTemplate,
+		},
+		{
+			name:     "default missing attribute",
+			content:  `team = {{ attr "team" "platform" | quote }} // {{ attr "region" "us" }} {{ attr "env" }}`,
+			template: true,
+			want:     `team = "platform" // eu prod`,
+		},
+		{
+			name:     "fail missing attribute without default",
+			content:  `team = {{ attr "team" }}`,
+			template: true,
+			wantErr:  ErrAttrMissing,
+		},
+		{
+			name:     "fail syntax",
+			content:  `team = {{ .Attributes.team `,
+			template: true,
+			wantErr:  ErrRenderTemplate,
+		},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			conf, err := New("file://"+path, nil, WithTemplate(tt.template))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := conf.Content(context.Background(), data)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
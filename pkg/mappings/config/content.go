@@ -5,9 +5,9 @@ import (
 	"context"
 	"errors"
 	"io"
-	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 var (
@@ -60,36 +60,27 @@ func fileHandler(ctx context.Context, path string) ([]byte, error) {
 	return content, ctx.Err()
 }
 
-// func ftpHandler(ctx context.Context, path string) ([]byte, error) {
-// 	return nil, nil
-// }
-
-func httpHandler(ctx context.Context, path string, headers http.Header) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", path, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header = headers
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	content, err := ioRead(res.Body)
+// revision of a file is its modification time
+func fileRevision(path string) string {
+	stat, err := os.Stat(path)
 	if err != nil {
-		return nil, errors.Join(ErrGetContent, err)
-	}
-	if err != res.Body.Close() {
-		return nil, err
+		return ""
 	}
-	return content, nil
+	return stat.ModTime().UTC().Format(time.RFC3339Nano)
 }
 
+// func ftpHandler(ctx context.Context, path string) ([]byte, error) {
+// 	return nil, nil
+// }
+
 func ioRead(input io.Reader) ([]byte, error) {
 	buf := make([]byte, 1024)
 	var output bytes.Buffer
 
 	for {
 		n, err := input.Read(buf)
+		// readers may return the last bytes together with io.EOF
+		output.Write(buf[:n])
 
 		if err == io.EOF {
 			break // End of file, break the loop
@@ -97,7 +88,6 @@ func ioRead(input io.Reader) ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
-		output.Write(buf[:n])
 	}
 	return output.Bytes(), nil
 }
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"errors"
+)
+
+var ErrFallbackMode = errors.New("unknown fallback mode, use 'stale' or 'fail'")
+
+// FallbackMode defines what is served if the source of a config fails
+type FallbackMode uint8
+
+const (
+	// serves the last content that was fetched and parsed, if any
+	FallbackStale FallbackMode = iota
+	// fails the request
+	FallbackFail
+)
+
+func ParseFallbackMode(raw string) (FallbackMode, error) {
+	switch raw {
+	case "", "stale":
+		return FallbackStale, nil
+	case "fail":
+		return FallbackFail, nil
+	default:
+		return FallbackStale, ErrFallbackMode
+	}
+}
+
+func (m FallbackMode) String() string {
+	switch m {
+	case FallbackFail:
+		return "fail"
+	default:
+		return "stale"
+	}
+}
+
+// ContentState is filled by Content if passed as option
+type ContentState struct {
+	// the source failed and the last known good content was served
+	Stale bool
+	// error of the failed source
+	Err error
+	// the rollout state changed, e.g. a rollout started, and has to be persisted
+	RolloutChanged bool
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Fallback(t *testing.T) {
+	good := "logging {\n  level = \"info\"\n}\n"
+	tests := []struct {
+		name      string
+		mode      FallbackMode
+		wantStale bool
+		wantErr   bool
+	}{
+		{
+			name:      "serve stale",
+			mode:      FallbackStale,
+			wantStale: true,
+		},
+		{
+			name:    "fail closed",
+			mode:    FallbackFail,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "conf.alloy")
+			if err := os.WriteFile(path, []byte(good), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			conf, err := New("file://"+path, nil, WithFallback(tt.mode))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			var state ContentState
+			if _, err := conf.Content(ctx, &state); err != nil {
+				t.Fatal(err)
+			}
+			assert.False(t, state.Stale)
+
+			if err := os.Remove(path); err != nil {
+				t.Fatal(err)
+			}
+			content, err := conf.Content(ctx, &state)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Content() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.wantStale, state.Stale)
+			if tt.wantStale {
+				assert.Equal(t, good, content)
+				assert.ErrorIs(t, state.Err, ErrFileOpen)
+			}
+		})
+	}
+}
+
+func Test_FallbackWithoutContent(t *testing.T) {
+	conf, err := New("file://"+filepath.Join(t.TempDir(), "missing.alloy"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var state ContentState
+	_, err = conf.Content(context.Background(), &state)
+	assert.ErrorIs(t, err, ErrFileOpen)
+	assert.False(t, state.Stale)
+}
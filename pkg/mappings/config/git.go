@@ -0,0 +1,260 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/myLogic207/go-arcs/pkg/store"
+)
+
+const (
+	gitProtoPrefix = "git+"
+	// separates the repository from the file path in git sources
+	gitPathDelimiter = "//"
+	// upper limit for a background fetch of a mirror
+	gitFetchTimeout = 2 * time.Minute
+)
+
+var (
+	ErrGitSource = errors.New("git source malformed, make sure source looks like git+[proto]://[repository]//[path]?ref=[ref]")
+	ErrGitClone  = errors.New("could not clone git repository")
+	ErrGitFetch  = errors.New("could not fetch git repository")
+	ErrGitShow   = errors.New("could not read file from git repository")
+
+	// directory the git mirrors are kept in
+	GitMirrorDir = filepath.Join(os.TempDir(), "go-arcs", "git")
+	// interval a mirror is fetched in, unless the mapping sets a ttl
+	GitFetchInterval = time.Minute
+
+	defaultMirrors = &gitMirrors{
+		mirrors: make(map[string]*gitMirror),
+	}
+)
+
+type gitSource struct {
+	remote string
+	path   string
+	ref    string
+}
+
+// parses sources like git+https://host/repo.git//path/to/conf.alloy?ref=main
+func parseGitSource(protocol string, path string) (gitSource, error) {
+	path, rawQuery, _ := strings.Cut(path, "?")
+	repository, file, found := strings.Cut(path, gitPathDelimiter)
+	if !found || repository == "" || file == "" {
+		return gitSource{}, ErrGitSource
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return gitSource{}, errors.Join(ErrGitSource, err)
+	}
+
+	remoteProto := strings.TrimPrefix(protocol, gitProtoPrefix)
+	source := gitSource{
+		remote: remoteProto + ProtoDelimiter + repository,
+		path:   file,
+		ref:    query.Get("ref"),
+	}
+	if source.ref == "" {
+		source.ref = "HEAD"
+	}
+	if err := checkRef(source.ref); err != nil {
+		return gitSource{}, err
+	}
+	return source, nil
+}
+
+// refs are passed to git and must not be mistaken for options, they follow
+// the rules of git check-ref-format --allow-onelevel without running git
+func checkRef(ref string) error {
+	invalid := fmt.Errorf("%w: invalid ref %q", ErrGitSource, ref)
+	if ref == "" || ref == "@" || strings.HasPrefix(ref, "-") ||
+		strings.HasPrefix(ref, "/") || strings.HasSuffix(ref, "/") || strings.HasSuffix(ref, ".") ||
+		strings.Contains(ref, "..") || strings.Contains(ref, "//") || strings.Contains(ref, "@{") {
+		return invalid
+	}
+	if strings.ContainsFunc(ref, func(r rune) bool {
+		return r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r)
+	}) {
+		return invalid
+	}
+	for _, component := range strings.Split(ref, "/") {
+		if strings.HasPrefix(component, ".") || strings.HasSuffix(component, ".lock") {
+			return invalid
+		}
+	}
+	return nil
+}
+
+type gitMirrors struct {
+	mirrors map[string]*gitMirror
+	mu      sync.Mutex
+	// running background clones and fetches
+	fetches sync.WaitGroup
+}
+
+// gitMirror is a local mirror clone of a remote repository
+type gitMirror struct {
+	remote    string
+	dir       string
+	cloned    bool
+	lastFetch time.Time
+	fetching  bool
+	fetches   *sync.WaitGroup
+	// closed once the running clone finished, nil if none is running
+	cloning  chan struct{}
+	cloneErr error
+	mu       sync.Mutex
+}
+
+func (m *gitMirrors) get(remote string) *gitMirror {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mirror, ok := m.mirrors[remote]
+	if !ok {
+		mirror = &gitMirror{
+			remote:  remote,
+			dir:     filepath.Join(GitMirrorDir, store.Hash([]byte(remote))+".git"),
+			fetches: &m.fetches,
+		}
+		m.mirrors[remote] = mirror
+	}
+	return mirror
+}
+
+// gitHandler returns the file of a git source at its ref and the commit of the ref,
+// the mirror is fetched in the background if older than interval
+func gitHandler(ctx context.Context, source gitSource, interval time.Duration) ([]byte, string, error) {
+	mirror := defaultMirrors.get(source.remote)
+	if err := mirror.update(ctx, interval); err != nil {
+		return nil, "", err
+	}
+	content, err := git(ctx, "--git-dir", mirror.dir, "show", "--end-of-options", fmt.Sprintf("%v:%v", source.ref, source.path))
+	if err != nil {
+		return nil, "", errors.Join(ErrGitShow, err)
+	}
+	commit, err := git(ctx, "--git-dir", mirror.dir, "rev-parse", "--verify", "--end-of-options", source.ref+"^{commit}")
+	if err != nil {
+		return nil, "", errors.Join(ErrGitShow, err)
+	}
+	return content, strings.TrimSpace(string(commit)), nil
+}
+
+// clones the mirror if missing, or starts a background fetch if older than interval,
+// requests are served from the existing mirror meanwhile
+func (m *gitMirror) update(ctx context.Context, interval time.Duration) error {
+	if err := m.clone(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fetching || time.Since(m.lastFetch) < interval {
+		return nil
+	}
+	m.fetching = true
+	m.fetches.Add(1)
+	go m.fetch()
+	return nil
+}
+
+// clones the mirror once if missing, the clone is not bound to the waiting requests,
+// so a slow first clone blocks no other remote and a cancelled request aborts it for no one
+func (m *gitMirror) clone(ctx context.Context) error {
+	m.mu.Lock()
+	if m.cloned {
+		m.mu.Unlock()
+		return nil
+	}
+	if m.cloning == nil {
+		m.cloning = make(chan struct{})
+		m.fetches.Add(1)
+		go m.runClone(m.cloning)
+	}
+	cloning := m.cloning
+	m.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return errors.Join(ErrGitClone, ctx.Err())
+	case <-cloning:
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cloned {
+		return nil
+	}
+	return m.cloneErr
+}
+
+func (m *gitMirror) runClone(done chan struct{}) {
+	defer m.fetches.Done()
+	err := m.cloneMissing()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cloned = err == nil
+	m.cloneErr = err
+	m.cloning = nil
+	close(done)
+}
+
+// mirrors of an earlier run are reused
+func (m *gitMirror) cloneMissing() error {
+	if _, err := os.Stat(m.dir); !errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(m.dir), 0o750); err != nil {
+		return errors.Join(ErrGitClone, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), gitFetchTimeout)
+	defer cancel()
+	if _, err := git(ctx, "clone", "--mirror", "--quiet", "--", m.remote, m.dir); err != nil {
+		os.RemoveAll(m.dir)
+		return errors.Join(ErrGitClone, err)
+	}
+	m.mu.Lock()
+	m.lastFetch = time.Now()
+	m.mu.Unlock()
+	return nil
+}
+
+// fetches the mirror without blocking requests on a slow remote,
+// a failing fetch keeps serving the existing mirror until the next interval
+func (m *gitMirror) fetch() {
+	defer m.fetches.Done()
+	ctx, cancel := context.WithTimeout(context.Background(), gitFetchTimeout)
+	defer cancel()
+	_, err := git(ctx, "--git-dir", m.dir, "fetch", "--prune", "--quiet")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fetching = false
+	m.lastFetch = time.Now()
+	if err != nil {
+		slog.Warn("Serving stale mirror", "remote", m.remote, "error", errors.Join(ErrGitFetch, err))
+	}
+}
+
+func git(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	// never wait for credentials on a terminal
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git %v: %w: %v", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// commits content to path in a work tree and pushes it to origin
+func gitCommit(t *testing.T, work string, path string, content string, args ...string) {
+	if err := os.WriteFile(filepath.Join(work, path), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, cmd := range [][]string{
+		{"add", path},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", content},
+		append([]string{"push", "--quiet", "origin", "HEAD:main"}, args...),
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", work}, cmd...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", cmd, err, out)
+		}
+	}
+}
+
+func Test_ParseGitSource(t *testing.T) {
+	tests := []struct {
+		name     string
+		protocol string
+		path     string
+		want     gitSource
+		wantErr  bool
+	}{
+		{
+			name:     "https with ref",
+			protocol: "git+https",
+			path:     "host/repo.git//path/to/conf.alloy?ref=main",
+			want:     gitSource{remote: "https://host/repo.git", path: "path/to/conf.alloy", ref: "main"},
+		},
+		{
+			name:     "ssh without ref",
+			protocol: "git+ssh",
+			path:     "git@host/repo.git//conf.alloy",
+			want:     gitSource{remote: "ssh://git@host/repo.git", path: "conf.alloy", ref: "HEAD"},
+		},
+		{
+			name:     "plain git",
+			protocol: "git",
+			path:     "host/repo.git//conf.alloy?ref=v1.0.0",
+			want:     gitSource{remote: "git://host/repo.git", path: "conf.alloy", ref: "v1.0.0"},
+		},
+		{
+			name:     "fail ref as option",
+			protocol: "git+https",
+			path:     "host/repo.git//conf.alloy?ref=--output=/tmp/x",
+			wantErr:  true,
+		},
+		{
+			name:     "fail malformed ref",
+			protocol: "git+https",
+			path:     "host/repo.git//conf.alloy?ref=main..other",
+			wantErr:  true,
+		},
+		{
+			name:     "fail without path",
+			protocol: "git+https",
+			path:     "host/repo.git",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGitSource(tt.protocol, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseGitSource() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_CheckRef(t *testing.T) {
+	tests := []struct {
+		ref     string
+		wantErr bool
+	}{
+		{ref: "HEAD"},
+		{ref: "main"},
+		{ref: "refs/heads/feature/x"},
+		{ref: "v1.0.0"},
+		{ref: "0a1b2c3d4e5f"},
+		{ref: "", wantErr: true},
+		{ref: "@", wantErr: true},
+		{ref: "--output=/tmp/x", wantErr: true},
+		{ref: "main..other", wantErr: true},
+		{ref: "main@{1}", wantErr: true},
+		{ref: "main~1", wantErr: true},
+		{ref: "main^", wantErr: true},
+		{ref: "a:b", wantErr: true},
+		{ref: "a b", wantErr: true},
+		{ref: "a\\b", wantErr: true},
+		{ref: "a*", wantErr: true},
+		{ref: "main.lock", wantErr: true},
+		{ref: "feature/.hidden", wantErr: true},
+		{ref: "feature/", wantErr: true},
+		{ref: "feature//x", wantErr: true},
+		{ref: "main.", wantErr: true},
+		{ref: "main\x7f", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			err := checkRef(tt.ref)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrGitSource)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_GitContent(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	GitMirrorDir = t.TempDir()
+	// background fetches have to finish before the mirrors are removed
+	t.Cleanup(defaultMirrors.fetches.Wait)
+	dir := t.TempDir()
+	bare := filepath.Join(dir, "repo.git")
+	work := filepath.Join(dir, "work")
+	for _, cmd := range [][]string{
+		{"init", "--quiet", "--bare", "--initial-branch=main", bare},
+		{"clone", "--quiet", bare, work},
+	} {
+		if out, err := exec.Command("git", cmd...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", cmd, err, out)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(work, "conf"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	gitCommit(t, work, "conf/remote.alloy", "// first")
+	if out, err := exec.Command("git", "-C", work, "tag", "v1").CombinedOutput(); err != nil {
+		t.Fatalf("git tag: %v\n%s", err, out)
+	}
+	gitCommit(t, work, "conf/remote.alloy", "// second", "--tags")
+
+	ctx := context.Background()
+	// fetch on every request
+	latest, err := New("git+file://"+bare+"//conf/remote.alloy?ref=main", nil, WithTTL(-1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinned, err := New("git+file://"+bare+"//conf/remote.alloy?ref=v1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a cancelled request does not abort the clone for others
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = latest.Content(cancelled)
+	assert.ErrorIs(t, err, context.Canceled)
+	defaultMirrors.fetches.Wait()
+	_, err = os.Stat(defaultMirrors.get("file://" + bare).dir)
+	assert.NoError(t, err)
+
+	content, err := latest.Content(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "// second", content)
+	content, err = pinned.Content(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "// first", content)
+	// the revision is the commit of the ref
+	if versions := pinned.Versions(); assert.Len(t, versions, 1) {
+		assert.Regexp(t, "^[0-9a-f]{40}$", versions[0].Revision)
+	}
+
+	// picked up once the background fetch finished
+	gitCommit(t, work, "conf/remote.alloy", "// third")
+	assert.Eventually(t, func() bool {
+		content, err := latest.Content(ctx)
+		return err == nil && content == "// third"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	missing, err := New("git+file://"+bare+"//missing.alloy", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = missing.Content(ctx)
+	assert.ErrorIs(t, err, ErrGitShow)
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/myLogic207/go-arcs/pkg/store"
+)
+
+var (
+	// HistorySize bounds the content versions kept per config
+	HistorySize = 10
+
+	ErrVersionNotFound = errors.New("version not found in history")
+)
+
+// Version is content of a config as it was fetched
+type Version struct {
+	Hash    string    `json:"hash"`
+	Fetched time.Time `json:"fetched"`
+	// revision of the source, e.g. the git commit or ETag
+	Revision string `json:"revision,omitempty"`
+	Content  []byte `json:"content"`
+}
+
+// restores a pinned version
+func withPinned(version *Version) Option {
+	return func(c *config) error {
+		c.pinned = version
+		return nil
+	}
+}
+
+// records content as new version if it changed, c.mu has to be held
+func (c *config) record(raw []byte, revision string) {
+	hash := store.Hash(raw)
+	if n := len(c.history); n > 0 && c.history[n-1].Hash == hash {
+		return
+	}
+	c.history = append(c.history, Version{
+		Hash:     hash,
+		Fetched:  time.Now(),
+		Revision: revision,
+		Content:  raw,
+	})
+	if limit := max(HistorySize, 1); len(c.history) > limit {
+		c.history = slices.Clone(c.history[len(c.history)-limit:])
+	}
+}
+
+func (c *config) Versions() []Version {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	versions := slices.Clone(c.history)
+	slices.Reverse(versions)
+	return versions
+}
+
+func (c *config) Pin(hash string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, version := range c.history {
+		if version.Hash == hash {
+			c.pinned = &version
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %v of %v", ErrVersionNotFound, hash, c.Source())
+}
+
+func (c *config) Unpin() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pinned == nil {
+		return false
+	}
+	c.pinned = nil
+	return true
+}
+
+func (c *config) Pinned() (Version, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pinned == nil {
+		return Version{}, false
+	}
+	return *c.pinned, true
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/myLogic207/go-arcs/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_History(t *testing.T) {
+	defer func(size int) { HistorySize = size }(HistorySize)
+	HistorySize = 3
+	path := filepath.Join(t.TempDir(), "conf.alloy")
+	conf, err := New("file://"+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	fetch := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := conf.Content(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := range 5 {
+		fetch(fmt.Sprintf("// v%v\n", i))
+		// unchanged content is no new version
+		fetch(fmt.Sprintf("// v%v\n", i))
+	}
+	// content that does not parse is no version either
+	os.WriteFile(path, []byte("logging {"), 0o600)
+	conf.Content(ctx)
+
+	versions := conf.Versions()
+	if !assert.Len(t, versions, 3) {
+		return
+	}
+	assert.Equal(t, "// v4\n", string(versions[0].Content))
+	assert.Equal(t, "// v2\n", string(versions[2].Content))
+	assert.Equal(t, store.Hash([]byte("// v2\n")), versions[2].Hash)
+	assert.NotEmpty(t, versions[0].Revision)
+	assert.False(t, versions[0].Fetched.IsZero())
+}
+
+func Test_Pin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conf.alloy")
+	conf, err := New("file://"+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	fetch := func(content string) string {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		content, err := conf.Content(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return content
+	}
+
+	fetch("// good\n")
+	fetch("// broken\n")
+	assert.ErrorIs(t, conf.Pin("unknown"), ErrVersionNotFound)
+	assert.NoError(t, conf.Pin(store.Hash([]byte("// good\n"))))
+	assert.Equal(t, "// good\n", fetch("// fixed\n"))
+	pinned, ok := conf.Pinned()
+	assert.True(t, ok)
+	assert.Equal(t, "// good\n", string(pinned.Content))
+
+	// the source is not fetched while pinned
+	os.Remove(path)
+	content, err := conf.Content(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "// good\n", content)
+
+	assert.True(t, conf.Unpin())
+	assert.False(t, conf.Unpin())
+	assert.Equal(t, "// fixed\n", fetch("// fixed\n"))
+}
+
+func Test_PinReload(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "conf.alloy")
+	mappings := filepath.Join(dir, "mappings.yaml")
+	writeMappings(t, mappings, "- source: 'file://"+path+"'\n  attributes:\n    test: value\n")
+	initial, err := Load(ctx, mappings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configStore := store.NewStore[Config](nil, nil)
+	configStore.Load(ctx, initial)
+	reloader := NewReloader(ctx, mappings, configStore, initial, nil)
+	fetch := func(content string) string {
+		writeMappings(t, path, content)
+		content, err := configStore.Get(ctx, initial[0].ID()).Content(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return content
+	}
+	fetch("// good\n")
+	fetch("// broken\n")
+	if err := initial[0].Pin(store.Hash([]byte("// good\n"))); err != nil {
+		t.Fatal(err)
+	}
+
+	// updating the mapping keeps the pin and the history
+	writeMappings(t, mappings, "- source: 'file://"+path+"'\n  attributes:\n    test: other\n")
+	if _, err := reloader.Reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "// good\n", fetch("// broken\n"))
+	assert.Len(t, configStore.Get(ctx, initial[0].ID()).Versions(), 2)
+
+	// so does a restart
+	data, err := Codec{}.Marshal(configStore.Get(ctx, initial[0].ID()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := Codec{}.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configStore.Set(ctx, restored)
+	assert.Equal(t, "// good\n", fetch("// broken\n"))
+	pinned, ok := restored.Pinned()
+	assert.True(t, ok)
+	assert.Equal(t, store.Hash([]byte("// good\n")), pinned.Hash)
+}
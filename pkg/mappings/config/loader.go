@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"slices"
+	"time"
 
+	"github.com/myLogic207/go-arcs/pkg/store"
 	"golang.org/x/sync/errgroup"
 	"gopkg.in/yaml.v3"
 )
@@ -13,13 +15,74 @@ var (
 	ErrLoadConfig = errors.New("cloud not load config")
 	ErrConfFields = errors.New("config entry does not contain needed fields 'source' and 'attribute'")
 	ErrConfCreate = errors.New("could not create config")
+	ErrConfTTL    = errors.New("could not parse ttl")
 )
 
 type Parser[t any] func([]byte) ([]t, error)
 
-type configRaw struct {
-	Source     string            `yaml:"source"`
-	Attributes map[string]string `yaml:"attributes"`
+// Mapping is the serialized form of a config as found in the mapping files
+type Mapping struct {
+	Source     string            `yaml:"source" json:"source"`
+	Attributes map[string]string `yaml:"attributes" json:"attributes,omitempty"`
+	// 'all' (default) or 'any' of the attributes have to match
+	Match string `yaml:"match,omitempty" json:"match,omitempty"`
+	// selector expressions like 'env in (prod,staging), !canary'
+	Selector string `yaml:"selector,omitempty" json:"selector,omitempty"`
+	// render the content as go template with the collector details
+	Template bool `yaml:"template,omitempty" json:"template,omitempty"`
+	// configs are composed by priority, lower first, then by source
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+	// duration remote content is cached for, overrides the Cache-Control of the source
+	TTL string `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+	// the server is not ready while the source is unreachable
+	Required bool `yaml:"required,omitempty" json:"required,omitempty"`
+	// 'stale' (default) serves the last known good content if the source fails, 'fail' fails the request
+	Fallback string `yaml:"fallback,omitempty" json:"fallback,omitempty"`
+	// stages changed content to canary collectors first
+	Rollout *RolloutPolicy `yaml:"rollout,omitempty" json:"rollout,omitempty"`
+	// added through the management services instead of the mapping files, only persisted
+	Runtime bool `yaml:"-" json:"runtime,omitempty"`
+	// progress of the rollout, only persisted so restarts do not bypass canaries
+	RolloutState *RolloutState `yaml:"-" json:"rollout_state,omitempty"`
+	// version delivered instead of the source, only persisted so pins survive restarts
+	Pinned *Version `yaml:"-" json:"pinned,omitempty"`
+}
+
+// Build creates and validates the config described by the mapping
+func (m Mapping) Build() (Config, error) {
+	match, err := store.ParseMatchMode(m.Match)
+	if err != nil {
+		return nil, err
+	}
+	selector, err := store.ParseSelector(m.Selector)
+	if err != nil {
+		return nil, err
+	}
+	fallback, err := ParseFallbackMode(m.Fallback)
+	if err != nil {
+		return nil, err
+	}
+	var ttl time.Duration
+	if m.TTL != "" {
+		if ttl, err = time.ParseDuration(m.TTL); err != nil {
+			return nil, errors.Join(ErrConfTTL, err)
+		}
+	}
+	return New(
+		m.Source,
+		m.Attributes,
+		WithMatch(match),
+		WithSelector(selector),
+		WithTemplate(m.Template),
+		WithPriority(m.Priority),
+		WithTTL(ttl),
+		WithRequired(m.Required),
+		WithFallback(fallback),
+		WithRollout(m.Rollout),
+		WithRuntime(m.Runtime),
+		withRolloutState(m.RolloutState),
+		withPinned(m.Pinned),
+	)
 }
 
 // Load the Server configuration mappings from a file or directory
@@ -35,7 +98,7 @@ func Load(ctx context.Context, path string) ([]Config, error) {
 		eg.Go(func() error {
 			content, err := fileHandler(eCtx, file)
 			if err != nil {
-				return nil
+				return err
 			}
 			configs[i], err = ParseConfig(content)
 			return err
@@ -48,7 +111,7 @@ func Load(ctx context.Context, path string) ([]Config, error) {
 }
 
 func ParseConfig(content []byte) ([]Config, error) {
-	var rawConfigs []configRaw
+	var rawConfigs []Mapping
 	err := yaml.Unmarshal(content, &rawConfigs)
 	if err != nil {
 		return nil, err
@@ -57,7 +120,7 @@ func ParseConfig(content []byte) ([]Config, error) {
 	configs := make([]Config, len(rawConfigs))
 	var errs error
 	for i, conf := range rawConfigs {
-		conf, err := New(conf.Source, conf.Attributes)
+		conf, err := conf.Build()
 		if err != nil {
 			errs = errors.Join(errs, err)
 		}
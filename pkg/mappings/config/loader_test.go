@@ -71,6 +71,41 @@ func Test_ParseRaw(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Load match any",
+			args: args{
+				content: []byte(`
+- source: 'file://test'
+  match: any
+  attributes:
+    test: value
+    test2: value2`),
+			},
+			want: []Config{
+				&config{
+					protocol: "file",
+					path:     "test",
+					attributes: map[string]string{
+						"test":  "value",
+						"test2": "value2",
+					},
+					match: store.MatchAny,
+					id:    store.Hash([]byte("file://test")),
+				},
+			},
+		},
+		{
+			name: "Fail unknown match",
+			args: args{
+				content: []byte(`
+- source: 'file://test'
+  match: some
+  attributes:
+    test: value`),
+			},
+			want:    []Config{nil},
+			wantErr: true,
+		},
 		{
 			name: "Fail malformed path",
 			args: args{
@@ -157,3 +192,27 @@ func Test_LoadFile(t *testing.T) {
 	}
 
 }
+
+func Test_ParseSelector(t *testing.T) {
+	configs, err := ParseConfig([]byte(`
+- source: 'file://prod'
+  selector: 'env in (prod,staging), !canary'
+- source: 'file://team'
+  selector: 'region != eu'
+  attributes:
+    team: a`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, configs[0].Matches(map[string]string{"env": "staging"}))
+	assert.False(t, configs[0].Matches(map[string]string{"env": "staging", "canary": "true"}))
+	assert.True(t, configs[1].Matches(map[string]string{"team": "a", "region": "us"}))
+	assert.False(t, configs[1].Matches(map[string]string{"team": "b", "region": "us"}))
+	assert.Equal(t, "region != eu", configs[1].Selector())
+
+	_, err = ParseConfig([]byte(`
+- source: 'file://broken'
+  selector: 'env in ()'`))
+	assert.ErrorIs(t, err, store.ErrSelectorSyntax)
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"time"
+
+	"github.com/myLogic207/go-arcs/pkg/store"
+)
+
+// Option sets optional behaviour of a config mapping
+type Option func(*config) error
+
+// WithMatch sets how the attributes are matched against requested attributes
+func WithMatch(mode store.MatchMode) Option {
+	return func(c *config) error {
+		c.match = mode
+		return nil
+	}
+}
+
+// WithSelector adds selector expressions that have to match as well
+func WithSelector(selector *store.Selector) Option {
+	return func(c *config) error {
+		if !selector.Empty() {
+			c.selector = selector
+		}
+		return nil
+	}
+}
+
+// WithTemplate renders the content as go template for every request
+func WithTemplate(template bool) Option {
+	return func(c *config) error {
+		c.template = template
+		return nil
+	}
+}
+
+// WithPriority sets the position when composed with other configs, lower first
+func WithPriority(priority int) Option {
+	return func(c *config) error {
+		c.priority = priority
+		return nil
+	}
+}
+
+// WithTTL caches remote content for ttl instead of following Cache-Control
+func WithTTL(ttl time.Duration) Option {
+	return func(c *config) error {
+		c.ttl = ttl
+		return nil
+	}
+}
+
+// WithRequired marks the source as required for the server to be ready
+func WithRequired(required bool) Option {
+	return func(c *config) error {
+		c.required = required
+		return nil
+	}
+}
+
+// WithFallback sets what is served if the source fails
+func WithFallback(mode FallbackMode) Option {
+	return func(c *config) error {
+		c.fallback = mode
+		return nil
+	}
+}
+
+// WithRuntime marks configs added through the management services,
+// they are never removed by reloading the mapping files
+func WithRuntime(runtime bool) Option {
+	return func(c *config) error {
+		c.runtime = runtime
+		return nil
+	}
+}
+
+// WithRollout stages changed content to canaries first
+func WithRollout(policy *RolloutPolicy) Option {
+	return func(c *config) error {
+		rollout, err := policy.build()
+		if err != nil {
+			return err
+		}
+		c.rollout = rollout
+		return nil
+	}
+}
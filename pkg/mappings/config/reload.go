@@ -0,0 +1,273 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/myLogic207/go-arcs/pkg/metrics"
+)
+
+// time to wait for further file events before reloading
+const reloadDebounce = 500 * time.Millisecond
+
+var (
+	ErrReload = errors.New("reload rejected, keeping current configs")
+)
+
+// Diff lists the sources changed by a reload
+type Diff struct {
+	Added   []string
+	Updated []string
+	Removed []string
+}
+
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Updated) == 0 && len(d.Removed) == 0
+}
+
+func (d Diff) String() string {
+	return fmt.Sprintf("added %v, updated %v, removed %v", d.Added, d.Updated, d.Removed)
+}
+
+// Reloader keeps a config store in sync with the mappings at a path.
+// Only configs loaded from mapping files are ever removed from the store,
+// configs added at runtime are kept.
+type Reloader struct {
+	path  string
+	store Store
+	// serializes changes to the store with other writers
+	storeMu sync.Locker
+	// configs loaded from path by id
+	loaded map[string]Config
+	// error of the last reload
+	lastErr error
+	mu      sync.Mutex
+}
+
+// NewReloader keeps store in sync with path, configs in the store that were not
+// added at runtime (e.g. restored from an earlier mapping file) are removed by the
+// next reload unless they are still mapped. storeMu is held while changing the store,
+// it has to be shared with other writers like the management services, nil if there are none.
+func NewReloader(ctx context.Context, path string, store Store, initial []Config, storeMu sync.Locker) *Reloader {
+	if storeMu == nil {
+		storeMu = &sync.Mutex{}
+	}
+	loaded := make(map[string]Config, len(initial))
+	for _, conf := range store.List(ctx) {
+		if !ToMapping(conf).Runtime {
+			loaded[conf.ID()] = conf
+		}
+	}
+	for _, conf := range initial {
+		loaded[conf.ID()] = conf
+	}
+	return &Reloader{
+		path:    path,
+		store:   store,
+		storeMu: storeMu,
+		loaded:  loaded,
+	}
+}
+
+// Reload loads the mappings again and applies the difference to the store,
+// nothing is applied if any mapping fails to load
+func (r *Reloader) Reload(ctx context.Context) (Diff, error) {
+	diff, err := r.apply(ctx)
+	metrics.ObserveReload(err)
+	r.mu.Lock()
+	r.lastErr = err
+	r.mu.Unlock()
+	return diff, err
+}
+
+// LastError returns the error of the last reload, nil if it succeeded
+func (r *Reloader) LastError() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lastErr
+}
+
+func (r *Reloader) apply(ctx context.Context) (Diff, error) {
+	configs, err := Load(ctx, r.path)
+	if err != nil {
+		return Diff{}, errors.Join(ErrReload, err)
+	}
+
+	r.storeMu.Lock()
+	defer r.storeMu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var diff Diff
+	var errs error
+	loaded := make(map[string]Config, len(configs))
+	for _, conf := range configs {
+		loaded[conf.ID()] = conf
+		existing := r.store.Get(ctx, conf.ID())
+		switch {
+		case existing == nil:
+			diff.Added = append(diff.Added, conf.Source())
+		case !equal(existing, conf):
+			diff.Updated = append(diff.Updated, conf.Source())
+			Inherit(conf, existing)
+		default:
+			continue
+		}
+		if _, err := r.store.Set(ctx, conf); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+	for id, conf := range r.loaded {
+		if _, ok := loaded[id]; ok {
+			continue
+		}
+		diff.Removed = append(diff.Removed, conf.Source())
+		if _, err := r.store.Remove(ctx, id); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+	r.loaded = loaded
+
+	return diff, errs
+}
+
+func equal(a Config, b Config) bool {
+	return reflect.DeepEqual(declared(a), declared(b))
+}
+
+// the mapping of a config without the state learned from its content
+func declared(c Config) Mapping {
+	m := ToMapping(c)
+	m.RolloutState = nil
+	m.Pinned = nil
+	return m
+}
+
+func (r *Reloader) reload(ctx context.Context, reason string) {
+	diff, err := r.Reload(ctx)
+	if err != nil {
+		slog.Error("Failed to reload configs", "path", r.path, "reason", reason, "error", err)
+		return
+	}
+	if diff.Empty() {
+		slog.Info("Reloaded configs, nothing changed", "path", r.path, "reason", reason)
+		return
+	}
+	slog.Info("Reloaded configs", "path", r.path, "reason", reason,
+		"added", diff.Added, "updated", diff.Updated, "removed", diff.Removed)
+}
+
+// Watch reloads whenever the mappings change until ctx is done.
+// Uses file system notifications and falls back to polling every interval.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	watcher, err := r.watcher()
+	if err != nil {
+		slog.Warn("Could not watch configs, polling instead", "path", r.path, "interval", interval, "error", err)
+		r.poll(ctx, interval)
+		return
+	}
+	defer watcher.Close()
+
+	// events are not matched by name, mounted config maps replace
+	// their files by swapping a symlinked directory
+	last := fingerprint(r.path)
+	debounce := time.NewTimer(reloadDebounce)
+	debounce.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			debounce.Stop()
+			return
+		case _, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			debounce.Reset(reloadDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			slog.Error("Error watching configs", "path", r.path, "error", err)
+		case <-debounce.C:
+			current := fingerprint(r.path)
+			if current == last {
+				continue
+			}
+			last = current
+			r.reload(ctx, "file changed")
+		}
+	}
+}
+
+func (r *Reloader) watcher() (*fsnotify.Watcher, error) {
+	abs, err := filepath.Abs(r.path)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := os.Stat(abs)
+	if err != nil {
+		return nil, err
+	}
+	// the parent of a single file is watched to survive editors replacing it
+	if !stat.IsDir() {
+		abs = filepath.Dir(abs)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(abs); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	return watcher, nil
+}
+
+func (r *Reloader) poll(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last := fingerprint(r.path)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current := fingerprint(r.path)
+			if current == last {
+				continue
+			}
+			last = current
+			r.reload(ctx, "file changed")
+		}
+	}
+}
+
+// summarizes names, sizes and modification times of the mapping files
+func fingerprint(path string) string {
+	files, err := GetFileOrFiles(path)
+	if err != nil {
+		return err.Error()
+	}
+	var print string
+	for _, file := range files {
+		stat, err := os.Stat(file)
+		if err != nil {
+			print += file + err.Error()
+			continue
+		}
+		print += fmt.Sprintf("%v:%v:%v;", file, stat.Size(), stat.ModTime().UnixNano())
+	}
+	return print
+}
@@ -0,0 +1,211 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/myLogic207/go-arcs/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeMappings(t *testing.T, path string, content string) {
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_Reload(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "mappings.yaml")
+	writeMappings(t, path, `
+- source: 'file://keep'
+  attributes:
+    test: value
+- source: 'file://update'
+  attributes:
+    test: value
+- source: 'file://remove'
+  attributes:
+    test: value`)
+
+	initial, err := Load(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configStore := store.NewStore[Config](nil, nil)
+	configStore.Load(ctx, initial)
+	// added at runtime, must survive reloads
+	runtime, _ := New("file://runtime", map[string]string{"test": "value"}, WithRuntime(true))
+	configStore.Set(ctx, runtime)
+	reloader := NewReloader(ctx, path, configStore, initial, nil)
+
+	writeMappings(t, path, `
+- source: 'file://keep'
+  attributes:
+    test: value
+- source: 'file://update'
+  attributes:
+    test: other
+- source: 'file://add'
+  attributes:
+    test: value`)
+	diff, err := reloader.Reload(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"file://add"}, diff.Added)
+	assert.Equal(t, []string{"file://update"}, diff.Updated)
+	assert.Equal(t, []string{"file://remove"}, diff.Removed)
+	assert.Len(t, configStore.List(ctx), 4)
+	assert.NotNil(t, configStore.Get(ctx, runtime.ID()))
+	assert.Len(t, configStore.GetByAttributes(ctx, map[string]string{"test": "other"}), 1)
+
+	// broken entries reject the whole reload
+	writeMappings(t, path, `
+- source: 'file://keep'
+  attributes:
+    test: value
+- source: 'broken'
+  attributes:
+    test: value`)
+	_, err = reloader.Reload(ctx)
+	assert.ErrorIs(t, err, ErrReload)
+	assert.Len(t, configStore.List(ctx), 4)
+}
+
+func Test_ReloadRestored(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "mappings.yaml")
+	writeMappings(t, path, `
+- source: 'file://keep'
+  attributes:
+    test: value`)
+	initial, err := Load(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// restored from a persistent store, deleted from the mappings while the server was down
+	configStore := store.NewStore[Config](nil, nil)
+	deleted, _ := New("file://deleted", map[string]string{"test": "value"})
+	runtime, _ := New("file://runtime", map[string]string{"test": "value"}, WithRuntime(true))
+	configStore.Load(ctx, []Config{deleted, runtime})
+	configStore.Load(ctx, initial)
+
+	diff, err := NewReloader(ctx, path, configStore, initial, nil).Reload(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"file://deleted"}, diff.Removed)
+	assert.Nil(t, configStore.Get(ctx, deleted.ID()))
+	assert.NotNil(t, configStore.Get(ctx, runtime.ID()))
+	assert.Len(t, configStore.List(ctx), 2)
+}
+
+func Test_Watch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mappings.yaml")
+	writeMappings(t, path, `
+- source: 'file://first'
+  attributes:
+    test: value`)
+
+	initial, err := Load(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configStore := store.NewStore[Config](nil, nil)
+	configStore.Load(ctx, initial)
+	go NewReloader(ctx, dir, configStore, initial, nil).Watch(ctx, 100*time.Millisecond)
+	// give the watcher time to start
+	time.Sleep(100 * time.Millisecond)
+
+	writeMappings(t, filepath.Join(dir, "more.yaml"), `
+- source: 'file://second'
+  attributes:
+    test: value`)
+	assert.Eventually(t, func() bool {
+		return len(configStore.List(ctx)) == 2
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func Test_WatchConfigMap(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// mounted config maps link their files into a directory that is swapped on updates
+	dir := t.TempDir()
+	version := func(name string, content string) {
+		if err := os.Mkdir(filepath.Join(dir, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		writeMappings(t, filepath.Join(dir, name, "mappings.yaml"), content)
+		if err := os.Symlink(name, filepath.Join(dir, "..data_tmp")); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	version("..v1", "- source: 'file://first'\n  attributes:\n    test: value\n")
+	path := filepath.Join(dir, "mappings.yaml")
+	if err := os.Symlink(filepath.Join("..data", "mappings.yaml"), path); err != nil {
+		t.Fatal(err)
+	}
+
+	initial, err := Load(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configStore := store.NewStore[Config](nil, nil)
+	configStore.Load(ctx, initial)
+	// polling is disabled, only events reload
+	go NewReloader(ctx, path, configStore, initial, nil).Watch(ctx, 0)
+	// give the watcher time to start
+	time.Sleep(100 * time.Millisecond)
+
+	version("..v2", "- source: 'file://first'\n  attributes:\n    test: value\n- source: 'file://second'\n  attributes:\n    test: value\n")
+	assert.Eventually(t, func() bool {
+		return len(configStore.List(ctx)) == 2
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func Test_ReloadKeepsState(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mappings.yaml")
+	content := filepath.Join(dir, "conf.alloy")
+	good := "logging {\n  level = \"info\"\n}\n"
+	writeMappings(t, content, good)
+	writeMappings(t, path, "- source: 'file://"+content+"'\n  attributes:\n    test: value\n")
+	initial, err := Load(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configStore := store.NewStore[Config](nil, nil)
+	configStore.Load(ctx, initial)
+	reloader := NewReloader(ctx, path, configStore, initial, nil)
+	if _, err := initial[0].Content(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	writeMappings(t, content, "logging {")
+	writeMappings(t, path, "- source: 'file://"+content+"'\n  attributes:\n    test: other\n")
+	diff, err := reloader.Reload(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, diff.Updated, 1)
+
+	// the rebuilt config still knows the last content that parsed
+	conf := configStore.Get(ctx, initial[0].ID())
+	served, err := conf.Content(ctx)
+	if assert.NoError(t, err) {
+		assert.Equal(t, good, served)
+	}
+	assert.ErrorIs(t, conf.ParseError(), ErrParseContent)
+}
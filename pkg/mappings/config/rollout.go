@@ -0,0 +1,235 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/myLogic207/go-arcs/pkg/metrics"
+	"github.com/myLogic207/go-arcs/pkg/store"
+)
+
+var (
+	ErrRollout   = errors.New("invalid rollout, set a percent between 1 and 100 or collectors")
+	ErrNoRollout = errors.New("no rollout in progress")
+)
+
+// RolloutPolicy stages changed content to canary collectors before all others receive it
+type RolloutPolicy struct {
+	// percent of collectors, chosen by the hash of their ID, receiving changed content first
+	Percent int `yaml:"percent,omitempty" json:"percent,omitempty"`
+	// IDs of collectors receiving changed content first
+	Collectors []string `yaml:"collectors,omitempty" json:"collectors,omitempty"`
+	// duration canaries have to poll healthily before the content is promoted, e.g. '10m'
+	Soak string `yaml:"soak,omitempty" json:"soak,omitempty"`
+}
+
+type rolloutPolicy struct {
+	percent    int
+	collectors []string
+	soak       time.Duration
+}
+
+func (p *RolloutPolicy) build() (rolloutPolicy, error) {
+	if p == nil {
+		return rolloutPolicy{}, nil
+	}
+	if p.Percent < 0 || p.Percent > 100 || (p.Percent == 0 && len(p.Collectors) == 0) {
+		return rolloutPolicy{}, ErrRollout
+	}
+	policy := rolloutPolicy{
+		percent:    p.Percent,
+		collectors: p.Collectors,
+	}
+	if p.Soak != "" {
+		soak, err := time.ParseDuration(p.Soak)
+		if err != nil {
+			return rolloutPolicy{}, errors.Join(ErrRollout, err)
+		}
+		policy.soak = soak
+	}
+	return policy, nil
+}
+
+func (p rolloutPolicy) enabled() bool {
+	return p.percent > 0 || len(p.collectors) > 0
+}
+
+func (p rolloutPolicy) serialize() *RolloutPolicy {
+	if !p.enabled() {
+		return nil
+	}
+	policy := &RolloutPolicy{
+		Percent:    p.percent,
+		Collectors: p.collectors,
+	}
+	if p.soak > 0 {
+		policy.Soak = p.soak.String()
+	}
+	return policy
+}
+
+// canaries are listed or their ID hashes into the percent
+func (p rolloutPolicy) selects(id string) bool {
+	if slices.Contains(p.collectors, id) {
+		return true
+	}
+	sum := sha256.Sum256([]byte(id))
+	return int(binary.BigEndian.Uint64(sum[:8])%100) < p.percent
+}
+
+// RolloutStatus is the state of rolling out changed content of a config
+type RolloutStatus struct {
+	// changed content is delivered to canaries only
+	Active bool
+	// when the content under rollout was first seen
+	Started time.Time
+	// when the content under rollout may be promoted
+	Due time.Time
+	// hash of the content every collector receives
+	StableHash string
+	// hash of the content under rollout
+	CanaryHash string
+	// hash of the last content rolled back, it is not rolled out again
+	RolledBackHash string
+	// why the due rollout is not promoted, e.g. no canaries are registered
+	Held string
+}
+
+// RolloutState is the progress of a rollout, persisted to survive restarts
+type RolloutState struct {
+	Stable     []byte    `json:"stable,omitempty"`
+	Canary     []byte    `json:"canary,omitempty"`
+	Started    time.Time `json:"started,omitzero"`
+	RolledBack string    `json:"rolled_back,omitempty"`
+}
+
+// restores the progress of a rollout
+func withRolloutState(state *RolloutState) Option {
+	return func(c *config) error {
+		if state != nil {
+			c.stable = state.Stable
+			c.canary = state.Canary
+			c.rolloutStart = state.Started
+			c.rolledBack = state.RolledBack
+		}
+		return nil
+	}
+}
+
+// progress of the rollout, nil if nothing was rolled out yet
+func (c *config) rolloutState() *RolloutState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.rollout.enabled() || c.stable == nil {
+		return nil
+	}
+	return &RolloutState{
+		Stable:     c.stable,
+		Canary:     c.canary,
+		Started:    c.rolloutStart,
+		RolledBack: c.rolledBack,
+	}
+}
+
+// tracks the content under rollout, returns the stable content if the collector
+// is no canary and has to receive it instead of raw, and whether the rollout state changed
+func (c *config) roll(raw []byte, id string) ([]byte, bool, bool) {
+	if !c.rollout.enabled() {
+		return nil, false, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	changed := false
+	switch {
+	case c.stable == nil:
+		// nothing to roll out from
+		c.stable = raw
+		return nil, false, true
+	case bytes.Equal(raw, c.stable):
+		// the source went back to the stable content
+		changed = c.canary != nil
+		c.canary = nil
+		c.held = ""
+		return nil, false, changed
+	case store.Hash(raw) == c.rolledBack:
+		return c.stable, true, false
+	case !bytes.Equal(raw, c.canary):
+		c.canary = raw
+		c.rolloutStart = time.Now()
+		c.held = ""
+		changed = true
+		slog.Info("Rolling out new content to canaries", "source", c.Source(), "hash", store.Hash(raw))
+		metrics.ObserveRollout(metrics.RolloutStarted)
+	}
+	if c.rollout.selects(id) {
+		return nil, false, changed
+	}
+	return c.stable, true, changed
+}
+
+func (c *config) Canary(id string) bool {
+	return c.rollout.enabled() && c.rollout.selects(id)
+}
+
+func (c *config) Rollout() RolloutStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status := RolloutStatus{
+		Active:         c.canary != nil,
+		RolledBackHash: c.rolledBack,
+		Held:           c.held,
+	}
+	if c.stable != nil {
+		status.StableHash = store.Hash(c.stable)
+	}
+	if c.canary != nil {
+		status.Started = c.rolloutStart
+		status.Due = c.rolloutStart.Add(c.rollout.soak)
+		status.CanaryHash = store.Hash(c.canary)
+	}
+	return status
+}
+
+func (c *config) Promote() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.canary == nil {
+		return fmt.Errorf("%w for %v", ErrNoRollout, c.Source())
+	}
+	c.stable = c.canary
+	c.canary = nil
+	c.held = ""
+	slog.Info("Promoted new content to all collectors", "source", c.Source(), "hash", store.Hash(c.stable))
+	metrics.ObserveRollout(metrics.RolloutPromoted)
+	return nil
+}
+
+func (c *config) Rollback() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.canary == nil {
+		return fmt.Errorf("%w for %v", ErrNoRollout, c.Source())
+	}
+	c.rolledBack = store.Hash(c.canary)
+	c.canary = nil
+	c.held = ""
+	slog.Warn("Rolled back new content", "source", c.Source(), "hash", c.rolledBack)
+	metrics.ObserveRollout(metrics.RolledBack)
+	return nil
+}
+
+func (c *config) Hold(hash string, reason string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.canary == nil || store.Hash(c.canary) != hash || c.held == reason {
+		return false
+	}
+	c.held = reason
+	return true
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/myLogic207/go-arcs/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RolloutPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  *RolloutPolicy
+		wantErr bool
+	}{
+		{name: "none"},
+		{name: "percent", policy: &RolloutPolicy{Percent: 10, Soak: "10m0s"}},
+		{name: "collectors", policy: &RolloutPolicy{Collectors: []string{"canary"}}},
+		{name: "fail without canaries", policy: &RolloutPolicy{Soak: "10m"}, wantErr: true},
+		{name: "fail above 100 percent", policy: &RolloutPolicy{Percent: 101}, wantErr: true},
+		{name: "fail on invalid soak", policy: &RolloutPolicy{Percent: 10, Soak: "soon"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := New("file://conf.alloy", nil, WithRollout(tt.policy))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WithRollout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				assert.Equal(t, tt.policy, ToMapping(conf).Rollout)
+			}
+		})
+	}
+}
+
+func Test_RolloutPercent(t *testing.T) {
+	policy := rolloutPolicy{percent: 20}
+	selected := 0
+	for i := range 1000 {
+		id := fmt.Sprintf("collector-%v", i)
+		if policy.selects(id) {
+			selected++
+		}
+		// deterministic per collector
+		assert.Equal(t, policy.selects(id), policy.selects(id))
+	}
+	assert.InDelta(t, 200, selected, 50)
+	assert.False(t, rolloutPolicy{}.selects("collector-1"))
+	assert.True(t, rolloutPolicy{percent: 100}.selects("collector-1"))
+}
+
+func Test_Rollout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conf.alloy")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conf, err := New("file://"+path, nil, WithRollout(&RolloutPolicy{Collectors: []string{"canary"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	content := func(id string) string {
+		content, err := conf.Content(ctx, TemplateData{ID: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return content
+	}
+
+	write("// v1\n")
+	assert.Equal(t, "// v1\n", content("canary"))
+	assert.Equal(t, "// v1\n", content("other"))
+	assert.False(t, conf.Rollout().Active)
+	assert.ErrorIs(t, conf.Promote(), ErrNoRollout)
+
+	write("// v2\n")
+	assert.Equal(t, "// v2\n", content("canary"))
+	assert.Equal(t, "// v1\n", content("other"))
+	assert.True(t, conf.Rollout().Active)
+	assert.NoError(t, conf.Promote())
+	assert.Equal(t, "// v2\n", content("other"))
+
+	write("// v3\n")
+	assert.Equal(t, "// v2\n", content("other"))
+	assert.Equal(t, "// v3\n", content("canary"))
+	assert.NoError(t, conf.Rollback())
+	// rolled back content is not rolled out again
+	assert.Equal(t, "// v2\n", content("canary"))
+	assert.False(t, conf.Rollout().Active)
+	assert.ErrorIs(t, conf.Rollback(), ErrNoRollout)
+
+	write("// v4\n")
+	assert.Equal(t, "// v4\n", content("canary"))
+	assert.Equal(t, "// v2\n", content("other"))
+	// the source went back to the stable content
+	write("// v2\n")
+	assert.Equal(t, "// v2\n", content("canary"))
+	assert.False(t, conf.Rollout().Active)
+}
+
+func Test_RolloutReload(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "conf.alloy")
+	mappings := filepath.Join(dir, "mappings.yaml")
+	writeMappings(t, path, "// v1\n")
+	writeMappings(t, mappings, "- source: 'file://"+path+"'\n  rollout:\n    collectors: [canary]\n")
+	initial, err := Load(ctx, mappings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configStore := store.NewStore[Config](nil, nil)
+	configStore.Load(ctx, initial)
+	reloader := NewReloader(ctx, mappings, configStore, initial, nil)
+	content := func(id string) string {
+		content, err := configStore.Get(ctx, initial[0].ID()).Content(ctx, TemplateData{ID: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return content
+	}
+	assert.Equal(t, "// v1\n", content("other"))
+	writeMappings(t, path, "// v2\n")
+	assert.Equal(t, "// v2\n", content("canary"))
+	started := configStore.Get(ctx, initial[0].ID()).Rollout().Started
+
+	// changing the mapping during the rollout keeps it going
+	writeMappings(t, mappings, "- source: 'file://"+path+"'\n  rollout:\n    collectors: [canary]\n    soak: 1m\n")
+	diff, err := reloader.Reload(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, diff.Updated, 1)
+	assert.Equal(t, "// v1\n", content("other"))
+	status := configStore.Get(ctx, initial[0].ID()).Rollout()
+	assert.True(t, status.Active)
+	assert.Equal(t, started, status.Started)
+
+	// restarting keeps it going as well
+	data, err := Codec{}.Marshal(configStore.Get(ctx, initial[0].ID()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := Codec{}.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rebuilt, err := Load(ctx, mappings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Inherit(rebuilt[0], restored)
+	configStore.Set(ctx, rebuilt[0])
+	assert.Equal(t, "// v1\n", content("other"))
+	assert.Equal(t, "// v2\n", content("canary"))
+	assert.Equal(t, status.Started.Unix(), rebuilt[0].Rollout().Started.Unix())
+
+	// the state is no change of the mapping
+	diff, err = reloader.Reload(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, diff.Empty())
+}
@@ -0,0 +1,330 @@
+package config
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3DefaultRegion = "us-east-1"
+	// sha256 of an empty payload
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	amzDateFormat    = "20060102T150405Z"
+)
+
+var (
+	ErrS3Source      = errors.New("s3 source malformed, make sure source looks like s3://[bucket]/[key]")
+	ErrS3Credentials = errors.New("no s3 credentials found in environment or shared credentials file")
+	ErrS3Endpoint    = errors.New("s3 endpoint not allowed, it has to be set in the environment or allowed explicitly")
+
+	// endpoints sources may send signed requests to, besides the one of the environment
+	S3Endpoints []string
+)
+
+var (
+	// both end up in the host of signed requests
+	s3RegionPattern = regexp.MustCompile(`^[a-z0-9-]+$`)
+	s3BucketPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+)
+
+type s3Source struct {
+	bucket    string
+	key       string
+	endpoint  string
+	region    string
+	pathStyle *bool
+}
+
+type awsCredentials struct {
+	accessKey    string
+	secretKey    string
+	sessionToken string
+}
+
+// parses sources like s3://bucket/path/to/key?endpoint=http://minio:9000&region=eu-central-1&path_style=true,
+// credentials are never part of the source
+func parseS3Source(path string) (s3Source, error) {
+	path, rawQuery, _ := strings.Cut(path, "?")
+	bucket, key, found := strings.Cut(path, "/")
+	if !found || key == "" || !s3BucketPattern.MatchString(bucket) || strings.Contains(bucket, "..") {
+		return s3Source{}, ErrS3Source
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return s3Source{}, errors.Join(ErrS3Source, err)
+	}
+
+	source := s3Source{
+		bucket:   bucket,
+		key:      key,
+		endpoint: query.Get("endpoint"),
+		region:   query.Get("region"),
+	}
+	if source.region != "" && !s3RegionPattern.MatchString(source.region) {
+		return s3Source{}, fmt.Errorf("%w: region %q", ErrS3Source, source.region)
+	}
+	if err := checkS3Endpoint(source.endpoint); err != nil {
+		return s3Source{}, err
+	}
+	if raw := query.Get("path_style"); raw != "" {
+		pathStyle, err := strconv.ParseBool(raw)
+		if err != nil {
+			return s3Source{}, errors.Join(ErrS3Source, err)
+		}
+		source.pathStyle = &pathStyle
+	}
+	return source, nil
+}
+
+// signed requests carry the session token, so they may only go to endpoints the operator chose
+func checkS3Endpoint(endpoint string) error {
+	if endpoint == "" {
+		return nil
+	}
+	allowed := append([]string{os.Getenv("AWS_ENDPOINT_URL_S3"), os.Getenv("AWS_ENDPOINT_URL")}, S3Endpoints...)
+	for _, candidate := range allowed {
+		if candidate != "" && strings.TrimSuffix(candidate, "/") == strings.TrimSuffix(endpoint, "/") {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %v", ErrS3Endpoint, endpoint)
+}
+
+// resolves region and endpoint from the source or the environment
+func (s s3Source) resolve() (string, string) {
+	region := cmp.Or(s.region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), s3DefaultRegion)
+	endpoint := cmp.Or(s.endpoint, os.Getenv("AWS_ENDPOINT_URL_S3"), os.Getenv("AWS_ENDPOINT_URL"))
+	return region, endpoint
+}
+
+// builds the object url, custom endpoints use path-style addressing unless disabled
+func (s s3Source) url() (string, string, error) {
+	region, endpoint := s.resolve()
+	pathStyle := endpoint != ""
+	if s.pathStyle != nil {
+		pathStyle = *s.pathStyle
+	}
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%v.amazonaws.com", region)
+	}
+	base, err := url.Parse(endpoint)
+	if err != nil {
+		return "", "", errors.Join(ErrS3Source, err)
+	}
+
+	prefix := strings.TrimSuffix(base.Path, "/") + "/"
+	if pathStyle {
+		prefix += s.bucket + "/"
+	} else {
+		base.Host = s.bucket + "." + base.Host
+	}
+	// keep the escaping of the key, it is signed as is
+	base.Path = prefix + s.key
+	base.RawPath = prefix + escapePath(s.key)
+	return base.String(), region, nil
+}
+
+// s3Handler fetches an object and its revision through the http cache with signed requests
+func s3Handler(ctx context.Context, source s3Source, ttl time.Duration) ([]byte, string, error) {
+	creds, err := loadAWSCredentials()
+	if err != nil {
+		return nil, "", err
+	}
+	objectURL, region, err := source.url()
+	if err != nil {
+		return nil, "", err
+	}
+	return defaultCache.get(ctx, objectURL, nil, ttl, func(req *http.Request) {
+		signV4(req, creds, region, "s3", time.Now())
+	})
+}
+
+// loads credentials from the environment, falling back to the shared credentials file
+func loadAWSCredentials() (awsCredentials, error) {
+	creds := awsCredentials{
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.accessKey != "" && creds.secretKey != "" {
+		return creds, nil
+	}
+
+	path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return creds, errors.Join(ErrS3Credentials, err)
+		}
+		path = filepath.Join(home, ".aws", "credentials")
+	}
+	profile := cmp.Or(os.Getenv("AWS_PROFILE"), "default")
+	return readCredentialsFile(path, profile)
+}
+
+// reads a profile of an ini style shared credentials file
+func readCredentialsFile(path string, profile string) (awsCredentials, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return awsCredentials{}, errors.Join(ErrS3Credentials, err)
+	}
+	defer file.Close()
+
+	var creds awsCredentials
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		if section != profile {
+			continue
+		}
+		key, value, _ := strings.Cut(line, "=")
+		switch strings.TrimSpace(key) {
+		case "aws_access_key_id":
+			creds.accessKey = strings.TrimSpace(value)
+		case "aws_secret_access_key":
+			creds.secretKey = strings.TrimSpace(value)
+		case "aws_session_token":
+			creds.sessionToken = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return awsCredentials{}, errors.Join(ErrS3Credentials, err)
+	}
+	if creds.accessKey == "" || creds.secretKey == "" {
+		return awsCredentials{}, ErrS3Credentials
+	}
+	return creds, nil
+}
+
+// signV4 signs a request without body with AWS signature version 4,
+// all headers set at this point are signed
+func signV4(req *http.Request, creds awsCredentials, region string, service string, now time.Time) {
+	amzDate := now.UTC().Format(amzDateFormat)
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	if creds.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%v:%v\n", name, strings.TrimSpace(headers[name]))
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		cmp.Or(req.URL.EscapedPath(), "/"),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		emptyPayloadHash,
+	}, "\n")
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hexHash(canonicalRequest),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%v/%v, SignedHeaders=%v, Signature=%v",
+		creds.accessKey,
+		scope,
+		signedHeaders,
+		signature,
+	))
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	var pairs []string
+	for _, key := range keys {
+		values := slices.Clone(query[key])
+		slices.Sort(values)
+		for _, value := range values {
+			pairs = append(pairs, escape(key)+"="+escape(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// escapes every segment of a path as required by signature version 4
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// uri encodes everything but unreserved characters
+func escape(raw string) string {
+	var out strings.Builder
+	for _, char := range []byte(raw) {
+		if (char >= 'A' && char <= 'Z') ||
+			(char >= 'a' && char <= 'z') ||
+			(char >= '0' && char <= '9') ||
+			char == '-' || char == '_' || char == '.' || char == '~' {
+			out.WriteByte(char)
+			continue
+		}
+		fmt.Fprintf(&out, "%%%02X", char)
+	}
+	return out.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hexHash(data string) string {
+	hash := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/diag"
+	"github.com/grafana/alloy/syntax/parser"
+	"github.com/myLogic207/go-arcs/pkg/store"
+)
+
+var ErrParseContent = errors.New("could not parse config content")
+
+// parses content as alloy syntax, every error carries source, line and column
+func parse(source string, content string) error {
+	_, err := parseFile(source, content)
+	return err
+}
+
+func parseFile(source string, content string) (*ast.File, error) {
+	file, err := parser.ParseFile(source, []byte(content))
+	if err == nil {
+		return file, nil
+	}
+	var diags diag.Diagnostics
+	if !errors.As(err, &diags) {
+		return nil, errors.Join(ErrParseContent, err)
+	}
+	errs := []error{ErrParseContent}
+	for _, d := range diags {
+		errs = append(errs, d)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// records content that parsed, it is served while newer content does not
+func (c *config) accept(raw []byte, revision string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.record(raw, revision)
+	c.good = raw
+	c.parseErr = nil
+	c.rejected = ""
+}
+
+// records the parse error of content and returns the last content that parsed, if any
+func (c *config) reject(raw []byte, err error) []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.parseErr = err
+	// log every rejected content once instead of on every poll
+	if hash := store.Hash(raw); hash != c.rejected {
+		c.rejected = hash
+		if c.good != nil {
+			slog.Warn("Serving last known good content", "source", c.Source(), "error", err)
+		} else {
+			slog.Error("Refusing content", "source", c.Source(), "error", err)
+		}
+	}
+	return c.good
+}
+
+// last raw content that parsed, if any
+func (c *config) lastGood() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.good
+}
+
+func (c *config) ParseError() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.parseErr
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "valid",
+			content: "logging {\n  level = \"info\"\n}\n\nprometheus.scrape \"default\" {\n  targets = []\n}\n",
+		},
+		{
+			name:    "comments only",
+			content: "// nothing to do\n",
+		},
+		{
+			name:    "unclosed block",
+			content: "logging {\n  level = \"info\"\n",
+			wantErr: "conf.alloy:3:1",
+		},
+		{
+			name:    "missing value",
+			content: "logging {\n  level =\n}\n",
+			wantErr: "conf.alloy:3:1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parse("conf.alloy", tt.content)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrParseContent)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func Test_ServeLastKnownGood(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conf.alloy")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conf, err := New("file://"+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// nothing to fall back to yet
+	write("logging {")
+	_, err = conf.Content(ctx)
+	assert.ErrorIs(t, err, ErrParseContent)
+	assert.ErrorIs(t, conf.ParseError(), ErrParseContent)
+
+	good := "logging {\n  level = \"info\"\n}\n"
+	write(good)
+	content, err := conf.Content(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, good, content)
+	assert.NoError(t, conf.ParseError())
+
+	write("logging {\n  level = \n}\n")
+	content, err = conf.Content(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, good, content)
+	assert.ErrorContains(t, conf.ParseError(), path+":3:1")
+}
+
+func Test_ServeLastKnownGoodTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conf.alloy")
+	if err := os.WriteFile(path, []byte("logging {\n  level = {{ quote .Attributes.level }}\n}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	conf, err := New("file://"+path, nil, WithTemplate(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	content, err := conf.Content(ctx, TemplateData{Attributes: map[string]string{"level": "info"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "logging {\n  level = \"info\"\n}\n", content)
+
+	if err := os.WriteFile(path, []byte("logging {\n  level = {{ .Attributes.level }}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// the last good template is rendered for the requesting collector
+	content, err = conf.Content(ctx, TemplateData{Attributes: map[string]string{"level": "debug"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "logging {\n  level = \"debug\"\n}\n", content)
+	// renderings fail per collector, the shared state is left alone
+	assert.NoError(t, conf.ParseError())
+}
+
+func Test_TemplateParsePerCollector(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conf.alloy")
+	if err := os.WriteFile(path, []byte("logging {\n  level = {{ .Attributes.level }}\n}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	conf, err := New("file://"+path, nil, WithTemplate(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	valid := TemplateData{ID: "valid", Attributes: map[string]string{"level": `"info"`}}
+	broken := TemplateData{ID: "broken", Attributes: map[string]string{"level": "{"}}
+
+	// a collector the template fails for neither replaces the last good content
+	// nor falls back to the very same template
+	for range 2 {
+		content, err := conf.Content(ctx, valid)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "logging {\n  level = \"info\"\n}\n", content)
+		}
+		_, err = conf.Content(ctx, broken)
+		assert.ErrorIs(t, err, ErrParseContent)
+		assert.NoError(t, conf.ParseError())
+		assert.Len(t, conf.Versions(), 1)
+	}
+}
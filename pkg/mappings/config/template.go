@@ -0,0 +1,74 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+var (
+	ErrRenderTemplate = errors.New("could not render config template")
+	ErrAttrMissing    = errors.New("attribute is missing and has no default")
+
+	templateFuncs = template.FuncMap{
+		// replaces empty values, use attr for attributes that might be missing
+		"default": func(fallback string, value string) string {
+			if value == "" {
+				return fallback
+			}
+			return value
+		},
+		"lower":   strings.ToLower,
+		"upper":   strings.ToUpper,
+		"replace": strings.ReplaceAll,
+		// quotes a value as alloy string literal
+		"quote": strconv.Quote,
+	}
+)
+
+// TemplateData is available when rendering templated config content
+type TemplateData struct {
+	// ID of the requesting collector
+	ID string
+	// Name the collector registered with
+	Name string
+	// Attributes the collector registered with
+	Attributes map[string]string
+	// RequestAttributes the collector sent with the current request
+	RequestAttributes map[string]string
+}
+
+// attr looks up an attribute of the request, then of the registration,
+// missing attributes fail unless a default is given
+func (d TemplateData) attr(key string, fallback ...string) (string, error) {
+	if value, ok := d.RequestAttributes[key]; ok {
+		return value, nil
+	}
+	if value, ok := d.Attributes[key]; ok {
+		return value, nil
+	}
+	if len(fallback) > 0 {
+		return fallback[0], nil
+	}
+	return "", fmt.Errorf("%w: %v", ErrAttrMissing, key)
+}
+
+// renders content as go text/template, accessing missing attributes fails
+func render(name string, content string, data TemplateData) (string, error) {
+	tmpl, err := template.New(name).
+		Option("missingkey=error").
+		Funcs(templateFuncs).
+		Funcs(template.FuncMap{"attr": data.attr}).
+		Parse(content)
+	if err != nil {
+		return "", errors.Join(ErrRenderTemplate, err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", errors.Join(ErrRenderTemplate, err)
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// collector details templates are rendered with during validation
+const validateCollector = "validate"
+
+var (
+	ErrDuplicateSource = errors.New("source is mapped more than once")
+	ErrOverlapSource   = errors.New("could not compose overlap, source failed")
+)
+
+// SourceResult is the outcome of fetching and rendering a single config
+type SourceResult struct {
+	Source string
+	Err    error
+}
+
+// Overlap are configs a collector with the attributes receives together
+type Overlap struct {
+	Attributes map[string]string
+	Sources    []string
+	// composing the content failed
+	Err error
+}
+
+// Report of validating config mappings
+type Report struct {
+	Sources  []SourceResult
+	Overlaps []Overlap
+}
+
+// Failed reports whether any source or overlap has an error
+func (r Report) Failed() bool {
+	for _, source := range r.Sources {
+		if source.Err != nil {
+			return true
+		}
+	}
+	for _, overlap := range r.Overlaps {
+		if overlap.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate fetches and renders every config and composes the content
+// of configs whose attributes overlap, as a matching collector would receive it
+func Validate(ctx context.Context, configs []Config) Report {
+	var report Report
+	seen := make(map[string]bool)
+	unique := make([]Config, 0, len(configs))
+	for _, conf := range configs {
+		if seen[conf.ID()] {
+			report.Sources = append(report.Sources, SourceResult{conf.Source(), ErrDuplicateSource})
+			continue
+		}
+		seen[conf.ID()] = true
+		unique = append(unique, conf)
+	}
+	Sort(unique)
+
+	results := make([]SourceResult, len(unique))
+	eg, egCtx := errgroup.WithContext(ctx)
+	for i, conf := range unique {
+		eg.Go(func() error {
+			_, err := validateContent(egCtx, conf, conf.Attributes())
+			results[i] = SourceResult{conf.Source(), err}
+			return nil
+		})
+	}
+	eg.Wait()
+	report.Sources = append(results, report.Sources...)
+
+	for _, overlap := range overlaps(unique) {
+		report.Overlaps = append(report.Overlaps, validateOverlap(ctx, overlap))
+	}
+	return report
+}
+
+// fetches and renders the content for a collector with attributes
+func validateContent(ctx context.Context, conf Config, attributes map[string]string) (string, error) {
+	return conf.Content(ctx, TemplateData{
+		ID:                validateCollector,
+		Name:              validateCollector,
+		Attributes:        attributes,
+		RequestAttributes: attributes,
+	})
+}
+
+type overlap struct {
+	attributes map[string]string
+	configs    []Config
+}
+
+// groups configs matched by the same attributes, the attributes of every
+// config and the union of every pair of configs are tried
+func overlaps(configs []Config) []overlap {
+	candidates := make([]map[string]string, 0, len(configs)*len(configs))
+	for i, a := range configs {
+		candidates = append(candidates, a.Attributes())
+		for _, b := range configs[i+1:] {
+			if union, ok := union(a.Attributes(), b.Attributes()); ok {
+				candidates = append(candidates, union)
+			}
+		}
+	}
+
+	var groups []overlap
+	seen := make(map[string]bool)
+	for _, attributes := range candidates {
+		var matched []Config
+		var ids []string
+		for _, conf := range configs {
+			if conf.Matches(attributes) {
+				matched = append(matched, conf)
+				ids = append(ids, conf.ID())
+			}
+		}
+		key := strings.Join(ids, ",")
+		if len(matched) < 2 || seen[key] {
+			continue
+		}
+		seen[key] = true
+		groups = append(groups, overlap{attributes, matched})
+	}
+	return groups
+}
+
+// merges attributes, fails if both have a different value for a key
+func union(a map[string]string, b map[string]string) (map[string]string, bool) {
+	merged := maps.Clone(a)
+	if merged == nil {
+		merged = make(map[string]string, len(b))
+	}
+	for key, val := range b {
+		if existing, ok := merged[key]; ok && existing != val {
+			return nil, false
+		}
+		merged[key] = val
+	}
+	return merged, true
+}
+
+func validateOverlap(ctx context.Context, group overlap) Overlap {
+	result := Overlap{Attributes: group.attributes}
+	fragments := make([]Fragment, 0, len(group.configs))
+	var failed []string
+	for _, conf := range group.configs {
+		result.Sources = append(result.Sources, conf.Source())
+		content, err := validateContent(ctx, conf, group.attributes)
+		if err != nil {
+			// the error itself is reported with the source
+			failed = append(failed, conf.Source())
+			continue
+		}
+		fragments = append(fragments, Fragment{conf.Source(), content})
+	}
+	if len(failed) > 0 {
+		result.Err = fmt.Errorf("%w: %v", ErrOverlapSource, strings.Join(failed, ", "))
+		return result
+	}
+	_, result.Err = Compose(fragments)
+	return result
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Validate(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.alloy":     "logging {\n  level = \"info\"\n}\n",
+		"prod.alloy":     "prometheus.scrape \"default\" {\n}\n",
+		"clash.alloy":    "logging {\n  level = \"debug\"\n}\n",
+		"template.alloy": "// {{ .Attributes.env }}\n",
+		"broken.alloy":   "// {{ .Attributes.env \n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mapping := func(name string, attributes map[string]string, options ...Option) Config {
+		conf, err := New("file://"+filepath.Join(dir, name), attributes, options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return conf
+	}
+
+	tests := []struct {
+		name         string
+		configs      []Config
+		wantFailed   bool
+		wantOverlaps int
+	}{
+		{
+			name: "disjoint attributes",
+			configs: []Config{
+				mapping("base.alloy", map[string]string{"env": "dev"}),
+				mapping("clash.alloy", map[string]string{"env": "prod"}),
+			},
+		},
+		{
+			name: "overlap composes",
+			configs: []Config{
+				mapping("base.alloy", map[string]string{"os": "linux"}),
+				mapping("prod.alloy", map[string]string{"env": "prod"}),
+				mapping("template.alloy", map[string]string{"env": "prod"}, WithTemplate(true)),
+			},
+			wantOverlaps: 2,
+		},
+		{
+			name: "overlap with duplicate blocks",
+			configs: []Config{
+				mapping("base.alloy", map[string]string{"os": "linux"}),
+				mapping("clash.alloy", map[string]string{"env": "prod"}),
+			},
+			wantFailed:   true,
+			wantOverlaps: 1,
+		},
+		{
+			name: "missing source",
+			configs: []Config{
+				mapping("missing.alloy", map[string]string{"env": "prod"}),
+			},
+			wantFailed: true,
+		},
+		{
+			name: "broken template",
+			configs: []Config{
+				mapping("broken.alloy", map[string]string{"env": "prod"}, WithTemplate(true)),
+			},
+			wantFailed: true,
+		},
+		{
+			name: "source mapped twice",
+			configs: []Config{
+				mapping("base.alloy", map[string]string{"env": "prod"}),
+				mapping("base.alloy", map[string]string{"env": "dev"}),
+			},
+			wantFailed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Validate(context.Background(), tt.configs)
+			assert.Equal(t, tt.wantFailed, report.Failed(), "%+v", report)
+			assert.Len(t, report.Overlaps, tt.wantOverlaps)
+		})
+	}
+}
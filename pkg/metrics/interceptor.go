@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+type interceptor struct{}
+
+// NewInterceptor records count, status code and duration of handled RPCs
+func NewInterceptor() connect.Interceptor {
+	return interceptor{}
+}
+
+func observe(procedure string, start time.Time, err error) {
+	code := "ok"
+	if err != nil {
+		code = connect.CodeOf(err).String()
+	}
+	rpcRequests.WithLabelValues(procedure, code).Inc()
+	rpcDuration.WithLabelValues(procedure).Observe(time.Since(start).Seconds())
+}
+
+func (interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		start := time.Now()
+		res, err := next(ctx, req)
+		observe(req.Spec().Procedure, start, err)
+		return res, err
+	}
+}
+
+func (interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		start := time.Now()
+		err := next(ctx, conn)
+		observe(conn.Spec().Procedure, start, err)
+		return err
+	}
+}
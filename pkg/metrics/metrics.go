@@ -0,0 +1,151 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/myLogic207/go-arcs/pkg/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "arcs"
+
+// results of a cache lookup
+const (
+	CacheHit         = "hit"
+	CacheRevalidated = "revalidated"
+	CacheStale       = "stale"
+	CacheMiss        = "miss"
+)
+
+// events of staged rollouts
+const (
+	RolloutStarted  = "started"
+	RolloutPromoted = "promoted"
+	RolledBack      = "rolled_back"
+)
+
+var (
+	// Registry holds all metrics of the server
+	Registry = prometheus.NewRegistry()
+
+	rpcRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_requests_total",
+		Help:      "RPCs handled by procedure and status code.",
+	}, []string{"procedure", "code"})
+	rpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_duration_seconds",
+		Help:      "Duration of handled RPCs by procedure.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"procedure"})
+	fetchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "config_fetch_duration_seconds",
+		Help:      "Duration of fetching config content by source protocol.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"protocol"})
+	fetchErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "config_fetch_errors_total",
+		Help:      "Failed fetches of config content by source protocol.",
+	}, []string{"protocol"})
+	cacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "config_cache_lookups_total",
+		Help:      "Lookups of the remote content cache by result (hit, revalidated, stale, miss).",
+	}, []string{"result"})
+	fallbacks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "config_fallbacks_total",
+		Help:      "Last known good content served as the source failed, by source protocol.",
+	}, []string{"protocol"})
+	rollouts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "config_rollouts_total",
+		Help:      "Staged rollouts of changed config content by event (started, promoted, rolled_back).",
+	}, []string{"event"})
+	reaped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "collectors_reaped_total",
+		Help:      "Collectors removed for not being seen within the ttl.",
+	})
+	reloads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "config_reloads_total",
+		Help:      "Reloads of the config mappings by result.",
+	}, []string{"result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		rpcRequests,
+		rpcDuration,
+		fetchDuration,
+		fetchErrors,
+		cacheLookups,
+		fallbacks,
+		rollouts,
+		reloads,
+		reaped,
+	)
+}
+
+// Handler serves the metrics of Registry
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveFetch records the duration and outcome of fetching config content
+func ObserveFetch(protocol string, duration time.Duration, err error) {
+	fetchDuration.WithLabelValues(protocol).Observe(duration.Seconds())
+	if err != nil {
+		fetchErrors.WithLabelValues(protocol).Inc()
+	}
+}
+
+// ObserveCache records the result of a cache lookup
+func ObserveCache(result string) {
+	cacheLookups.WithLabelValues(result).Inc()
+}
+
+// ObserveFallback records serving the last known good content of a failed source
+func ObserveFallback(protocol string) {
+	fallbacks.WithLabelValues(protocol).Inc()
+}
+
+// ObserveRollout records an event of a staged rollout
+func ObserveRollout(event string) {
+	rollouts.WithLabelValues(event).Inc()
+}
+
+// ObserveReload records the outcome of reloading the config mappings
+func ObserveReload(err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	reloads.WithLabelValues(result).Inc()
+}
+
+// ObserveReap records the removal of a stale collector
+func ObserveReap() {
+	reaped.Inc()
+}
+
+// StoreSize reports the number of objects in a store as gauge
+func StoreSize[t store.Object](name string, help string, objects store.Store[t]) prometheus.Collector {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	}, func() float64 {
+		return float64(len(objects.List(context.Background())))
+	})
+}
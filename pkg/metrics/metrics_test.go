@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	serverv1 "github.com/myLogic207/go-arcs/api/gen/proto/go/server/v1"
+	"github.com/myLogic207/go-arcs/api/gen/proto/go/server/v1/serverv1connect"
+	"github.com/myLogic207/go-arcs/pkg/store"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterceptor(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle(serverv1connect.NewConfigManagerHandler(
+		serverv1connect.UnimplementedConfigManagerHandler{},
+		connect.WithInterceptors(NewInterceptor()),
+	))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := serverv1connect.NewConfigManagerClient(server.Client(), server.URL)
+	for range 2 {
+		_, err := client.GetConfigMapping(context.Background(), connect.NewRequest(&serverv1.ConfigSourceRequest{}))
+		assert.Equal(t, connect.CodeUnimplemented, connect.CodeOf(err))
+	}
+	stream, err := client.ListConfigs(context.Background(), connect.NewRequest(&serverv1.ListRequest{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for stream.Receive() {
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(rpcRequests.WithLabelValues(
+		serverv1connect.ConfigManagerGetConfigMappingProcedure,
+		connect.CodeUnimplemented.String(),
+	)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(rpcRequests.WithLabelValues(
+		serverv1connect.ConfigManagerListConfigsProcedure,
+		connect.CodeUnimplemented.String(),
+	)))
+}
+
+func TestObserve(t *testing.T) {
+	ObserveFetch("file", time.Millisecond, nil)
+	ObserveFetch("file", time.Millisecond, errors.New("missing"))
+	ObserveCache(CacheHit)
+	ObserveReload(nil)
+	ObserveReload(errors.New("invalid"))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(fetchErrors.WithLabelValues("file")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(cacheLookups.WithLabelValues(CacheHit)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(reloads.WithLabelValues("failure")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(reloads.WithLabelValues("success")))
+}
+
+func TestStoreSize(t *testing.T) {
+	objects := store.NewStore[store.Object](nil, nil)
+	gauge := StoreSize("test_objects", "Objects in the test store.", objects)
+	assert.Equal(t, 0.0, testutil.ToFloat64(gauge))
+
+	expected := "# HELP arcs_test_objects Objects in the test store.\n# TYPE arcs_test_objects gauge\narcs_test_objects 0\n"
+	assert.NoError(t, testutil.CollectAndCompare(gauge, strings.NewReader(expected)))
+}
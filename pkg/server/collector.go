@@ -3,12 +3,16 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
 	"maps"
+	"time"
 
 	"connectrpc.com/connect"
 	collectorv1 "github.com/grafana/alloy-remote-config/api/gen/proto/go/collector/v1"
 	serverv1 "github.com/myLogic207/go-arcs/api/gen/proto/go/server/v1"
+	"github.com/myLogic207/go-arcs/pkg/auth"
 	"github.com/myLogic207/go-arcs/pkg/mappings/collector"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 var (
@@ -16,20 +20,64 @@ var (
 	ErrCollectorExists        = errors.New("ID is already registered")
 	ErrCollectorRemove        = errors.New("could not remove collector")
 	ErrCollectorNotRegistered = errors.New("collector not registered")
+	ErrCredentialMismatch     = errors.New("collector is registered with a different credential")
 )
 
+// checks the request carries the credential the collector registered with
+func (s *Server) verifyCollector(ctx context.Context, col collector.Collector) error {
+	credential, err := s.policy.Credential(ctx, col.ID())
+	if err != nil {
+		return credentialError(err)
+	}
+	if credential != col.Credential() {
+		return credentialError(ErrCredentialMismatch)
+	}
+	return nil
+}
+
+// surfaces credential errors with a matching connect code
+func credentialError(err error) error {
+	if errors.Is(err, auth.ErrMissingCredential) {
+		return connect.NewError(connect.CodeUnauthenticated, err)
+	}
+	return connect.NewError(connect.CodePermissionDenied, err)
+}
+
 func (s *Server) GetCollector(
 	ctx context.Context,
 	req *connect.Request[serverv1.GetCollectorRequest],
 ) (*connect.Response[serverv1.GetCollectorsResponse], error) {
-	logRequest(req)
 	id := req.Msg.GetId()
 	col := s.collectors.Get(ctx, id)
-	return connect.NewResponse(&serverv1.GetCollectorsResponse{
+	if col == nil {
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("%w: %v", ErrCollectorNotRegistered, id))
+	}
+	return connect.NewResponse(s.collectorResponse(col)), nil
+}
+
+func (s *Server) collectorResponse(col collector.Collector) *serverv1.GetCollectorsResponse {
+	status := col.Status()
+	res := &serverv1.GetCollectorsResponse{
 		Id:              col.ID(),
 		LocalAttributes: col.Attributes(),
 		Name:            col.Name(),
-	}), nil
+		Peer:            status.Peer,
+		Hash:            status.Hash,
+		LastError:       status.LastError,
+		Override:        overrideResponse(col),
+	}
+	if !status.LastSeen.IsZero() {
+		res.LastSeen = timestamppb.New(status.LastSeen)
+	}
+	switch status.Health(time.Now(), s.staleAfter, s.lostAfter) {
+	case collector.HealthHealthy:
+		res.Health = serverv1.Health_HEALTH_HEALTHY
+	case collector.HealthStale:
+		res.Health = serverv1.Health_HEALTH_STALE
+	case collector.HealthLost:
+		res.Health = serverv1.Health_HEALTH_LOST
+	}
+	return res
 }
 
 func (s *Server) ListCollectors(
@@ -37,7 +85,6 @@ func (s *Server) ListCollectors(
 	req *connect.Request[serverv1.ListRequest],
 	stream *connect.ServerStream[serverv1.GetCollectorsResponse],
 ) error {
-	logRequest(req)
 	attributes := req.Msg.GetLocalAttributes()
 
 	var collectors []collector.Collector
@@ -48,11 +95,7 @@ func (s *Server) ListCollectors(
 	}
 
 	for _, col := range collectors {
-		stream.Send(&serverv1.GetCollectorsResponse{
-			Id:              col.ID(),
-			LocalAttributes: col.Attributes(),
-			Name:            col.Name(),
-		})
+		stream.Send(s.collectorResponse(col))
 	}
 	return nil
 }
@@ -61,21 +104,39 @@ func (s *Server) RegisterCollector(
 	ctx context.Context,
 	req *connect.Request[collectorv1.RegisterCollectorRequest],
 ) (*connect.Response[collectorv1.RegisterCollectorResponse], error) {
-	logRequest(req)
+	if err := checkIdentity(ctx, req.Msg.GetId()); err != nil {
+		return nil, err
+	}
+	credential, err := s.policy.Credential(ctx, req.Msg.GetId())
+	if err != nil {
+		return nil, credentialError(err)
+	}
 	col := collector.New(
 		req.Msg.GetId(),
 		req.Msg.GetName(),
 		req.Msg.GetLocalAttributes(),
 		"",
 	)
+	col.SetCredential(credential)
+	col.Seen(req.Peer().Addr, nil)
 
 	if existing := s.collectors.Get(ctx, col.ID()); existing != nil {
+		if existing.Credential() != "" && existing.Credential() != credential {
+			return nil, credentialError(ErrCredentialMismatch)
+		}
 		if col.ID() != existing.ID() ||
 			col.Name() != existing.Name() ||
 			!maps.Equal(col.Attributes(), existing.Attributes()) {
 			return nil, ErrCollectorExists
 		}
-		// collector already registered, nothing to do
+		existing.Seen(req.Peer().Addr, nil)
+		if existing.Credential() != credential {
+			// bind collectors registered before the policy required a credential
+			existing.SetCredential(credential)
+			if _, err := s.collectors.Set(ctx, existing); err != nil {
+				return nil, errors.Join(ErrCollectorAdd, err)
+			}
+		}
 	} else {
 		_, err := s.collectors.Set(ctx, col)
 		if err != nil {
@@ -85,12 +146,27 @@ func (s *Server) RegisterCollector(
 	return connect.NewResponse(&collectorv1.RegisterCollectorResponse{}), nil
 }
 
+// rejects registering an ID not named by the verified client certificate, if any
+func checkIdentity(ctx context.Context, id string) error {
+	if identity, ok := auth.IdentityFromContext(ctx); ok && !identity.Matches(id) {
+		return connect.NewError(
+			connect.CodePermissionDenied,
+			fmt.Errorf("%w %v, certificate names %v", auth.ErrIdentity, id, identity.Names()),
+		)
+	}
+	return nil
+}
+
 func (s *Server) UnregisterCollector(
 	ctx context.Context,
 	req *connect.Request[collectorv1.UnregisterCollectorRequest],
 ) (*connect.Response[collectorv1.UnregisterCollectorResponse], error) {
-	logRequest(req)
 	collectorID := req.Msg.GetId()
+	if col := s.collectors.Get(ctx, collectorID); col != nil {
+		if err := s.verifyCollector(ctx, col); err != nil {
+			return nil, err
+		}
+	}
 
 	_, err := s.collectors.Remove(ctx, collectorID)
 	if err != nil {
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	collectorv1 "github.com/grafana/alloy-remote-config/api/gen/proto/go/collector/v1"
+	"github.com/grafana/alloy-remote-config/api/gen/proto/go/collector/v1/collectorv1connect"
+	"github.com/myLogic207/go-arcs/pkg/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+// requests with this header are served as if they presented a verified client certificate
+// with the comma separated names, the first one as common name
+const testCertificateHeader = "X-Test-Certificate"
+
+func serveWithCertificates(s *Server) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if raw := r.Header.Get(testCertificateHeader); raw != "" {
+			names := strings.Split(raw, ",")
+			cert := &x509.Certificate{Subject: pkix.Name{CommonName: names[0]}, DNSNames: names[1:]}
+			r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		}
+		s.Handler.ServeHTTP(w, r)
+	}))
+}
+
+func TestCollectorBinding(t *testing.T) {
+	tokens, err := auth.ParseTokens([]byte(fmt.Sprintf(
+		"tokens:\n  - name: first\n    hash: %v\n    scopes: [enroll]\n  - name: second\n    hash: %v\n    scopes: [enroll]\n",
+		auth.HashToken("first-secret"),
+		auth.HashToken("second-secret"),
+	)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		options []Option
+		// sets the credential of the registered collector, or another one
+		present func(header http.Header, registered bool)
+	}{
+		{
+			name:    "token",
+			options: []Option{WithTokens(tokens), WithPolicy(auth.PolicyToken)},
+			present: func(header http.Header, registered bool) {
+				token := "second-secret"
+				if registered {
+					token = "first-secret"
+				}
+				header.Set("Authorization", "Bearer "+token)
+			},
+		},
+		{
+			name:    "mtls",
+			options: []Option{WithPolicy(auth.PolicyMTLS)},
+			present: func(header http.Header, registered bool) {
+				names := "collector,collector.example.com"
+				if !registered {
+					// matches the ID, but is a different certificate
+					names = "collector,other.example.com"
+				}
+				header.Set(testCertificateHeader, names)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			server := serveWithCertificates(New("", nil, nil, tt.options...))
+			defer server.Close()
+			client := collectorv1connect.NewCollectorServiceClient(server.Client(), server.URL)
+
+			register := connect.NewRequest(&collectorv1.RegisterCollectorRequest{Id: "collector"})
+			tt.present(register.Header(), true)
+			if _, err := client.RegisterCollector(ctx, register); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, registered := range []bool{false, true} {
+				getConfig := connect.NewRequest(&collectorv1.GetConfigRequest{Id: "collector"})
+				tt.present(getConfig.Header(), registered)
+				_, err := client.GetConfig(ctx, getConfig)
+				unregister := connect.NewRequest(&collectorv1.UnregisterCollectorRequest{Id: "collector"})
+				tt.present(unregister.Header(), registered)
+				_, unregisterErr := client.UnregisterCollector(ctx, unregister)
+				if registered {
+					assert.NoError(t, err)
+					assert.NoError(t, unregisterErr)
+					continue
+				}
+				assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err), "GetConfig with another credential")
+				assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(unregisterErr), "UnregisterCollector with another credential")
+			}
+		})
+	}
+}
@@ -3,11 +3,13 @@ package server
 import (
 	"context"
 	"errors"
+	"log/slog"
 	"net/http"
 	"strings"
 
 	"connectrpc.com/connect"
 	collectorv1 "github.com/grafana/alloy-remote-config/api/gen/proto/go/collector/v1"
+	"github.com/myLogic207/go-arcs/api"
 	serverv1 "github.com/myLogic207/go-arcs/api/gen/proto/go/server/v1"
 	"github.com/myLogic207/go-arcs/pkg/mappings/config"
 	"github.com/myLogic207/go-arcs/pkg/store"
@@ -15,14 +17,19 @@ import (
 )
 
 var (
-	ErrGetConfig = errors.New("failed to parse config")
+	ErrGetConfig      = errors.New("failed to parse config")
+	ErrConfigInvalid  = errors.New("invalid config mapping")
+	ErrConfigExists   = errors.New("source is already mapped")
+	ErrConfigNotFound = errors.New("source is not mapped")
+	ErrConfigAdd      = errors.New("could not add config")
+	ErrConfigRemove   = errors.New("could not remove config")
+	ErrConfigPersist  = errors.New("could not persist config state")
 )
 
 func (s *Server) GetConfig(
 	ctx context.Context,
 	req *connect.Request[collectorv1.GetConfigRequest],
 ) (*connect.Response[collectorv1.GetConfigResponse], error) {
-	logRequest(req)
 	// id := req.Msg.GetId()
 	attributes := req.Msg.GetLocalAttributes()
 	collectorID := req.Msg.GetId()
@@ -30,7 +37,12 @@ func (s *Server) GetConfig(
 	// check if collector is registered
 	collector := s.collectors.Get(ctx, collectorID)
 	if collector == nil {
-		return nil, ErrCollectorNotRegistered
+		var err error
+		if collector, err = s.reregister(ctx, collectorID, attributes, req.Peer().Addr); err != nil {
+			return nil, err
+		}
+	} else if err := s.verifyCollector(ctx, collector); err != nil {
+		return nil, err
 	}
 
 	currentHash := ""
@@ -42,46 +54,92 @@ func (s *Server) GetConfig(
 		collector.SetHash(reqHash)
 	}
 
-	configs := s.configs.GetByAttributes(ctx, attributes)
+	configs, err := s.overrideConfigs(ctx, collector, s.configs.GetByAttributes(ctx, attributes))
+	if err != nil {
+		collector.Seen(req.Peer().Addr, err)
+		return nil, configError(err)
+	}
 
-	config, err := getCollectorConfig(ctx, configs, req.Header())
+	data := config.TemplateData{
+		ID:                collector.ID(),
+		Name:              collector.Name(),
+		Attributes:        collector.Attributes(),
+		RequestAttributes: attributes,
+	}
+	config, stale, err := s.getCollectorConfig(ctx, configs, req.Header(), data)
+	collector.Seen(req.Peer().Addr, err)
 	if err != nil {
-		return nil, errors.Join(ErrGetConfig, err)
+		return nil, configError(err)
 	}
 	newHash := store.Hash([]byte(config))
-	modified := currentHash == newHash
-	if modified {
-		collector.SetHash(newHash)
+	notModified := currentHash == newHash
+	if collector.GetHash() != newHash {
+		collector.SetHash(config)
+		// store again so persistent stores record the delivered hash
+		if _, err := s.collectors.Set(ctx, collector); err != nil {
+			slog.Error("Failed to store hash of collector", "collector", collectorID, "error", err)
+		}
 	}
 
-	return connect.NewResponse(&collectorv1.GetConfigResponse{
+	res := connect.NewResponse(&collectorv1.GetConfigResponse{
 		Content:     config,
 		Hash:        newHash,
-		NotModified: modified,
-	}), nil
+		NotModified: notModified,
+	})
+	if len(stale) > 0 {
+		slog.Warn("Served last known good content", "collector", collectorID, "sources", stale)
+		res.Header().Set(api.StaleSourcesHeader, strings.Join(stale, ","))
+	}
+	return res, nil
 }
 
-func getCollectorConfig(
+// surfaces content errors with a matching connect code
+func configError(err error) error {
+	err = errors.Join(ErrGetConfig, err)
+	if errors.Is(err, config.ErrRenderTemplate) || errors.Is(err, config.ErrDuplicateComponent) {
+		return connect.NewError(connect.CodeFailedPrecondition, err)
+	}
+	return connect.NewError(connect.CodeInternal, err)
+}
+
+// fetches the content of all configs and composes them ordered by priority and source,
+// sources served from their last known good content are returned as stale
+func (s *Server) getCollectorConfig(
 	ctx context.Context,
 	configs []config.Config,
 	header http.Header,
-) (string, error) {
+	data config.TemplateData,
+) (string, []string, error) {
+	config.Sort(configs)
 	eg, getCtx := errgroup.WithContext(ctx)
-	results := make([]string, len(configs))
-	for i, config := range configs {
-		i, config := i, config // https://golang.org/doc/faq#closures_and_goroutines
+	fragments := make([]config.Fragment, len(configs))
+	states := make([]config.ContentState, len(configs))
+	for i, conf := range configs {
 		eg.Go(func() error {
-			content, err := config.Content(getCtx, header)
+			content, err := conf.Content(getCtx, header, data, &states[i])
 			if err == nil {
-				results[i] = content
+				fragments[i] = config.Fragment{
+					Source:  conf.Source(),
+					Content: content,
+				}
 			}
 			return err
 		})
 	}
 	if err := eg.Wait(); err != nil {
-		return "", err
+		return "", nil, err
+	}
+	var stale []string
+	for i, state := range states {
+		if state.Stale {
+			stale = append(stale, configs[i].Source())
+		}
+		if state.RolloutChanged {
+			s.persistRollout(ctx, configs[i])
+		}
 	}
-	return strings.Join(results, " "), nil
+	content, err := config.Compose(fragments)
+	return content, stale, err
 }
 
 func (s *Server) ListConfigs(
@@ -89,7 +147,6 @@ func (s *Server) ListConfigs(
 	req *connect.Request[serverv1.ListRequest],
 	stream *connect.ServerStream[serverv1.GetConfigResponse],
 ) error {
-	logRequest(req)
 	attributes := req.Msg.GetLocalAttributes()
 
 	var configs []config.Config
@@ -101,10 +158,141 @@ func (s *Server) ListConfigs(
 	}
 
 	for _, config := range configs {
-		stream.Send(&serverv1.GetConfigResponse{
-			Source:          config.Source(),
-			LocalAttributes: config.Attributes(),
-		})
+		stream.Send(configResponse(config))
+	}
+	return nil
+}
+
+func configResponse(conf config.Config) *serverv1.GetConfigResponse {
+	res := &serverv1.GetConfigResponse{
+		Source:          conf.Source(),
+		LocalAttributes: conf.Attributes(),
+		Match:           conf.Match().String(),
+		Selector:        conf.Selector(),
+		Template:        conf.Template(),
+		Priority:        int32(conf.Priority()),
+		Ttl:             config.ToMapping(conf).TTL,
+		Required:        conf.Required(),
+		Fallback:        conf.Fallback().String(),
+	}
+	res.Rollout, res.RolloutStatus = rolloutResponse(conf)
+	if pinned, ok := conf.Pinned(); ok {
+		res.PinnedHash = pinned.Hash
+	}
+	if err := conf.ParseError(); err != nil {
+		res.ParseError = err.Error()
+	}
+	return res
+}
+
+func configFromRequest(req *serverv1.ConfigMappingRequest) (config.Config, error) {
+	var rollout *config.RolloutPolicy
+	if req.GetRollout() != nil {
+		rollout = &config.RolloutPolicy{
+			Percent:    int(req.GetRollout().GetPercent()),
+			Collectors: req.GetRollout().GetCollectors(),
+			Soak:       req.GetRollout().GetSoak(),
+		}
+	}
+	conf, err := config.Mapping{
+		Source:     req.GetSource(),
+		Attributes: req.GetLocalAttributes(),
+		Match:      req.GetMatch(),
+		Selector:   req.GetSelector(),
+		Template:   req.GetTemplate(),
+		Priority:   int(req.GetPriority()),
+		TTL:        req.GetTtl(),
+		Required:   req.GetRequired(),
+		Fallback:   req.GetFallback(),
+		Rollout:    rollout,
+		Runtime:    true,
+	}.Build()
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.Join(ErrConfigInvalid, err))
+	}
+	return conf, nil
+}
+
+func (s *Server) GetConfigMapping(
+	ctx context.Context,
+	req *connect.Request[serverv1.ConfigSourceRequest],
+) (*connect.Response[serverv1.GetConfigResponse], error) {
+	conf := s.configs.Get(ctx, store.Hash([]byte(req.Msg.GetSource())))
+	if conf == nil {
+		return nil, connect.NewError(connect.CodeNotFound, ErrConfigNotFound)
+	}
+	return connect.NewResponse(configResponse(conf)), nil
+}
+
+func (s *Server) AddConfig(
+	ctx context.Context,
+	req *connect.Request[serverv1.ConfigMappingRequest],
+) (*connect.Response[serverv1.GetConfigResponse], error) {
+	conf, err := configFromRequest(req.Msg)
+	if err != nil {
+		return nil, err
+	}
+	s.mappingsMu.Lock()
+	defer s.mappingsMu.Unlock()
+	if existing := s.configs.Get(ctx, conf.ID()); existing != nil {
+		return nil, connect.NewError(connect.CodeAlreadyExists, ErrConfigExists)
+	}
+	if _, err := s.configs.Set(ctx, conf); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, errors.Join(ErrConfigAdd, err))
+	}
+	slog.Info("Added config", "source", conf.Source())
+	return connect.NewResponse(configResponse(conf)), nil
+}
+
+func (s *Server) UpdateConfig(
+	ctx context.Context,
+	req *connect.Request[serverv1.ConfigMappingRequest],
+) (*connect.Response[serverv1.GetConfigResponse], error) {
+	conf, err := configFromRequest(req.Msg)
+	if err != nil {
+		return nil, err
+	}
+	s.mappingsMu.Lock()
+	defer s.mappingsMu.Unlock()
+	existing := s.configs.Get(ctx, conf.ID())
+	if existing == nil {
+		return nil, connect.NewError(connect.CodeNotFound, ErrConfigNotFound)
+	}
+	config.Inherit(conf, existing)
+	if _, err := s.configs.Set(ctx, conf); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, errors.Join(ErrConfigAdd, err))
+	}
+	slog.Info("Updated config", "source", conf.Source())
+	return connect.NewResponse(configResponse(conf)), nil
+}
+
+func (s *Server) RemoveConfig(
+	ctx context.Context,
+	req *connect.Request[serverv1.ConfigSourceRequest],
+) (*connect.Response[serverv1.RemoveConfigResponse], error) {
+	s.mappingsMu.Lock()
+	defer s.mappingsMu.Unlock()
+	removed, err := s.configs.Remove(ctx, store.Hash([]byte(req.Msg.GetSource())))
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, errors.Join(ErrConfigRemove, err))
+	}
+	if removed {
+		slog.Info("Removed config", "source", req.Msg.GetSource())
+	}
+	return connect.NewResponse(&serverv1.RemoveConfigResponse{
+		Removed: removed,
+	}), nil
+}
+
+// writes the state of a config changed in place, e.g. a pin or rollout, to persistent stores,
+// s.mappingsMu has to be held so no newer mapping of the source is overwritten
+func (s *Server) persistConfig(ctx context.Context, conf config.Config) error {
+	if s.configs.Get(ctx, conf.ID()) != conf {
+		// replaced in the meantime, the new config inherited the state
+		return nil
+	}
+	if _, err := s.configs.Set(ctx, conf); err != nil {
+		return errors.Join(ErrConfigPersist, err)
 	}
 	return nil
 }
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/myLogic207/go-arcs/api"
+	serverv1 "github.com/myLogic207/go-arcs/api/gen/proto/go/server/v1"
+	"github.com/myLogic207/go-arcs/api/gen/proto/go/server/v1/serverv1connect"
+	"github.com/myLogic207/go-arcs/pkg/mappings/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetConfigFallback(t *testing.T) {
+	f := newFixture(t, nil)
+	stale := f.mapping("stale.alloy", "// stale.alloy", map[string]string{"env": "prod"})
+	fail := f.mapping("fail.alloy", "// fail.alloy", map[string]string{"env": "dev"}, config.WithFallback(config.FallbackFail))
+	f.load(stale, fail)
+
+	tests := []struct {
+		name      string
+		file      string
+		collector string
+		want      string
+		wantStale string
+		wantErr   error
+	}{
+		{name: "stale", file: "stale.alloy", collector: "prod", want: "// stale.alloy", wantStale: stale.Source()},
+		{name: "fail", file: "fail.alloy", collector: "dev", wantErr: config.ErrFileOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f.register(tt.collector, map[string]string{"env": tt.collector})
+			res, err := f.poll(tt.collector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Empty(t, res.Header().Get(api.StaleSourcesHeader))
+
+			f.remove(tt.file)
+			res, err = f.poll(tt.collector)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.want, res.Msg.GetContent())
+			assert.Equal(t, tt.wantStale, res.Header().Get(api.StaleSourcesHeader))
+		})
+	}
+}
+
+func TestManageConfigs(t *testing.T) {
+	ctx := context.Background()
+	s := New("", nil, nil)
+	server := httptest.NewServer(s.Handler)
+	defer server.Close()
+	client := serverv1connect.NewConfigManagerClient(server.Client(), server.URL)
+
+	mapping := &serverv1.ConfigMappingRequest{
+		Source:          "file://conf.alloy",
+		LocalAttributes: map[string]string{"env": "prod"},
+		Priority:        1,
+	}
+	source := connect.NewRequest(&serverv1.ConfigSourceRequest{Source: mapping.Source})
+
+	_, err := client.GetConfigMapping(ctx, source)
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	_, err = client.UpdateConfig(ctx, connect.NewRequest(mapping))
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+
+	added, err := client.AddConfig(ctx, connect.NewRequest(mapping))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int32(1), added.Msg.GetPriority())
+	_, err = client.AddConfig(ctx, connect.NewRequest(mapping))
+	assert.Equal(t, connect.CodeAlreadyExists, connect.CodeOf(err))
+
+	for _, invalid := range []*serverv1.ConfigMappingRequest{
+		{Source: "conf.alloy"},
+		{Source: "file://other.alloy", Match: "some"},
+		{Source: "file://other.alloy", Selector: "env in"},
+		{Source: "file://other.alloy", Ttl: "soon"},
+	} {
+		_, err = client.AddConfig(ctx, connect.NewRequest(invalid))
+		assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err), invalid.String())
+	}
+
+	mapping.Priority = 2
+	mapping.Template = true
+	if _, err := client.UpdateConfig(ctx, connect.NewRequest(mapping)); err != nil {
+		t.Fatal(err)
+	}
+	got, err := client.GetConfigMapping(ctx, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int32(2), got.Msg.GetPriority())
+	assert.True(t, got.Msg.GetTemplate())
+
+	list := func(attributes map[string]string) []*serverv1.GetConfigResponse {
+		stream, err := client.ListConfigs(ctx, connect.NewRequest(&serverv1.ListRequest{LocalAttributes: attributes}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var configs []*serverv1.GetConfigResponse
+		for stream.Receive() {
+			configs = append(configs, stream.Msg())
+		}
+		if err := stream.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return configs
+	}
+	if configs := list(nil); assert.Len(t, configs, 1) {
+		assert.Equal(t, mapping.Source, configs[0].GetSource())
+		assert.Equal(t, mapping.LocalAttributes, configs[0].GetLocalAttributes())
+		assert.Equal(t, int32(2), configs[0].GetPriority())
+	}
+	assert.Len(t, list(map[string]string{"env": "dev"}), 0)
+
+	removed, err := client.RemoveConfig(ctx, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, removed.Msg.GetRemoved())
+	removed, err = client.RemoveConfig(ctx, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, removed.Msg.GetRemoved())
+	assert.Empty(t, list(nil))
+}
+
+func TestAddConfigConcurrently(t *testing.T) {
+	ctx := context.Background()
+	s := New("", nil, nil)
+	var added atomic.Int32
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.AddConfig(ctx, connect.NewRequest(&serverv1.ConfigMappingRequest{Source: "file://conf.alloy"}))
+			if err == nil {
+				added.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), added.Load(), "only one concurrent add of a source succeeds")
+}
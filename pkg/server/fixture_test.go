@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"connectrpc.com/connect"
+	collectorv1 "github.com/grafana/alloy-remote-config/api/gen/proto/go/collector/v1"
+	"github.com/myLogic207/go-arcs/pkg/mappings/config"
+	"github.com/myLogic207/go-arcs/pkg/store"
+)
+
+// fixture is a server with configs from local source files and registered collectors
+type fixture struct {
+	*Server
+	t   *testing.T
+	ctx context.Context
+	// of the source files
+	dir string
+	// attributes the collectors register and poll with
+	attributes map[string]map[string]string
+}
+
+// newFixture serves from configs, an in-memory store if nil
+func newFixture(t *testing.T, configs config.Store, options ...Option) *fixture {
+	return &fixture{
+		Server:     New("", configs, nil, options...),
+		t:          t,
+		ctx:        context.Background(),
+		dir:        t.TempDir(),
+		attributes: make(map[string]map[string]string),
+	}
+}
+
+// write sets the content of the source file name and returns its source
+func (f *fixture) write(name string, content string) string {
+	path := filepath.Join(f.dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		f.t.Fatal(err)
+	}
+	return "file://" + path
+}
+
+// remove deletes the source file name
+func (f *fixture) remove(name string) {
+	if err := os.Remove(filepath.Join(f.dir, name)); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+// mapping of the source file name, which is created with content
+func (f *fixture) mapping(name string, content string, attributes map[string]string, options ...config.Option) config.Config {
+	conf, err := config.New(f.write(name, content), attributes, options...)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return conf
+}
+
+func (f *fixture) load(confs ...config.Config) {
+	if _, err := f.configs.Load(f.ctx, confs); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+func (f *fixture) register(id string, attributes map[string]string) {
+	f.attributes[id] = attributes
+	if _, err := f.RegisterCollector(f.ctx, connect.NewRequest(&collectorv1.RegisterCollectorRequest{
+		Id:              id,
+		LocalAttributes: attributes,
+	})); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+func (f *fixture) poll(id string) (*connect.Response[collectorv1.GetConfigResponse], error) {
+	return f.GetConfig(f.ctx, connect.NewRequest(&collectorv1.GetConfigRequest{
+		Id:              id,
+		LocalAttributes: f.attributes[id],
+	}))
+}
+
+// content the collector id gets delivered
+func (f *fixture) content(id string) string {
+	res, err := f.poll(id)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return res.Msg.GetContent()
+}
+
+func newDiskConfigs(t *testing.T, dir string) config.Store {
+	configs, err := store.NewDiskStore[config.Config](context.Background(), dir, "configs", config.Codec{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return configs
+}
+
+// restoreConfigs opens a copy of the configs logged to dir as a restart finds them,
+// a copy because opening a store compacts its log
+func restoreConfigs(t *testing.T, dir string) config.Store {
+	log, err := os.ReadFile(filepath.Join(dir, "configs.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	restoreDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(restoreDir, "configs.log"), log, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return newDiskConfigs(t, restoreDir)
+}
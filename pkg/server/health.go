@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"connectrpc.com/grpchealth"
+	"github.com/grafana/alloy-remote-config/api/gen/proto/go/collector/v1/collectorv1connect"
+	"github.com/myLogic207/go-arcs/api/gen/proto/go/server/v1/serverv1connect"
+	"github.com/myLogic207/go-arcs/pkg/mappings/config"
+	"golang.org/x/sync/errgroup"
+)
+
+// upper limit for all readiness checks of a probe
+const readinessTimeout = 5 * time.Second
+
+var (
+	ErrNotStarted       = errors.New("server is not serving")
+	ErrSourceDown       = errors.New("required source unreachable")
+	ErrUnknownService   = errors.New("unknown service")
+	ErrReadinessTimeout = errors.New("readiness check timed out")
+
+	services = []string{
+		collectorv1connect.CollectorServiceName,
+		serverv1connect.CollectorManagerName,
+		serverv1connect.ConfigManagerName,
+	}
+)
+
+// ReadinessCheck reports why the server is not ready to serve collectors
+type ReadinessCheck func(context.Context) error
+
+type readinessCheck struct {
+	name  string
+	check ReadinessCheck
+}
+
+// WithReadinessCheck adds a check that has to pass for the server to be ready
+func WithReadinessCheck(name string, check ReadinessCheck) Option {
+	return func(s *Server) {
+		s.checks = append(s.checks, readinessCheck{name, check})
+	}
+}
+
+// SetReady marks the server as serving, or as shutting down
+func (s *Server) SetReady(ready bool) {
+	s.ready.Store(ready)
+}
+
+// runs all readiness checks and returns the failed ones by name
+func (s *Server) readiness(ctx context.Context) map[string]error {
+	ctx, cancel := context.WithTimeoutCause(ctx, readinessTimeout, ErrReadinessTimeout)
+	defer cancel()
+	checks := append([]readinessCheck{
+		{"serving", s.checkServing},
+		{"sources", s.checkSources},
+	}, s.checks...)
+
+	errs := make([]error, len(checks))
+	var eg errgroup.Group
+	for i, check := range checks {
+		eg.Go(func() error {
+			errs[i] = check.check(ctx)
+			return nil
+		})
+	}
+	eg.Wait()
+
+	failed := make(map[string]error)
+	for i, check := range checks {
+		if errs[i] != nil {
+			failed[check.name] = errs[i]
+		}
+	}
+	return failed
+}
+
+func (s *Server) checkServing(context.Context) error {
+	if !s.ready.Load() {
+		return ErrNotStarted
+	}
+	return nil
+}
+
+// fetches the content of all required configs
+func (s *Server) checkSources(ctx context.Context) error {
+	var required []config.Config
+	for _, conf := range s.configs.List(ctx) {
+		if conf.Required() {
+			required = append(required, conf)
+		}
+	}
+	errs := make([]error, len(required))
+	var eg errgroup.Group
+	for i, conf := range required {
+		eg.Go(func() error {
+			if err := conf.Check(ctx); err != nil {
+				errs[i] = fmt.Errorf("%w %v: %w", ErrSourceDown, conf.Source(), err)
+			}
+			return nil
+		})
+	}
+	eg.Wait()
+	return errors.Join(errs...)
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "ok")
+}
+
+// responds with every failed check, or ok if ready
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	failed := s.readiness(r.Context())
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if len(failed) == 0 {
+		fmt.Fprintln(w, "ok")
+		return
+	}
+	w.WriteHeader(http.StatusServiceUnavailable)
+	names := make([]string, 0, len(failed))
+	for name := range failed {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "%v: %v\n", name, strings.ReplaceAll(failed[name].Error(), "\n", "; "))
+	}
+}
+
+// Check implements the grpc.health.v1 service with the readiness of the server
+func (s *Server) Check(ctx context.Context, req *grpchealth.CheckRequest) (*grpchealth.CheckResponse, error) {
+	if req.Service != "" && !slices.Contains(services, req.Service) {
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("%w %v", ErrUnknownService, req.Service))
+	}
+	if len(s.readiness(ctx)) > 0 {
+		return &grpchealth.CheckResponse{Status: grpchealth.StatusNotServing}, nil
+	}
+	return &grpchealth.CheckResponse{Status: grpchealth.StatusServing}, nil
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"connectrpc.com/connect"
+	"connectrpc.com/grpchealth"
+	"github.com/myLogic207/go-arcs/pkg/mappings/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadiness(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "remote.alloy")
+	var reloadErr error
+	s := New("", nil, nil, WithReadinessCheck("reload", func(context.Context) error {
+		return reloadErr
+	}))
+	conf, err := config.New("file://"+path, nil, config.WithRequired(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.configs.Set(ctx, conf); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(s.Handler)
+	defer server.Close()
+
+	probe := func(path string) (int, string) {
+		res, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.StatusCode, string(body)
+	}
+	grpcStatus := func() grpchealth.Status {
+		res, err := s.Check(ctx, &grpchealth.CheckRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Status
+	}
+
+	code, _ := probe("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+
+	code, body := probe("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, body, "serving: ")
+	assert.Contains(t, body, "sources: ")
+	assert.Equal(t, grpchealth.StatusNotServing, grpcStatus())
+
+	s.SetReady(true)
+	if err := os.WriteFile(path, []byte("content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	code, body = probe("/readyz")
+	assert.Equal(t, http.StatusOK, code, body)
+	assert.Equal(t, grpchealth.StatusServing, grpcStatus())
+
+	reloadErr = errors.New("invalid mapping")
+	code, body = probe("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "reload: invalid mapping\n", body)
+
+	// shutting down
+	reloadErr = nil
+	s.SetReady(false)
+	assert.Equal(t, grpchealth.StatusNotServing, grpcStatus())
+
+	_, err = s.Check(ctx, &grpchealth.CheckRequest{Service: "unknown"})
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+}
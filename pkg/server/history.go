@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"connectrpc.com/connect"
+	serverv1 "github.com/myLogic207/go-arcs/api/gen/proto/go/server/v1"
+	"github.com/myLogic207/go-arcs/pkg/mappings/config"
+	"github.com/myLogic207/go-arcs/pkg/store"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *Server) ListConfigVersions(
+	ctx context.Context,
+	req *connect.Request[serverv1.ConfigSourceRequest],
+) (*connect.Response[serverv1.ListConfigVersionsResponse], error) {
+	conf := s.configs.Get(ctx, store.Hash([]byte(req.Msg.GetSource())))
+	if conf == nil {
+		return nil, connect.NewError(connect.CodeNotFound, ErrConfigNotFound)
+	}
+	pinned, _ := conf.Pinned()
+	res := &serverv1.ListConfigVersionsResponse{}
+	for _, version := range conf.Versions() {
+		res.Versions = append(res.Versions, &serverv1.ConfigVersion{
+			Hash:     version.Hash,
+			Fetched:  timestamppb.New(version.Fetched),
+			Revision: version.Revision,
+			Content:  string(version.Content),
+			Pinned:   version.Hash == pinned.Hash,
+		})
+	}
+	return connect.NewResponse(res), nil
+}
+
+func (s *Server) PinConfig(
+	ctx context.Context,
+	req *connect.Request[serverv1.PinConfigRequest],
+) (*connect.Response[serverv1.GetConfigResponse], error) {
+	s.mappingsMu.Lock()
+	defer s.mappingsMu.Unlock()
+	conf := s.configs.Get(ctx, store.Hash([]byte(req.Msg.GetSource())))
+	if conf == nil {
+		return nil, connect.NewError(connect.CodeNotFound, ErrConfigNotFound)
+	}
+	if err := conf.Pin(req.Msg.GetHash()); err != nil {
+		if errors.Is(err, config.ErrVersionNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, err)
+		}
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	if err := s.persistConfig(ctx, conf); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	slog.Warn("Pinned config, source changes are not delivered", "source", conf.Source(), "hash", req.Msg.GetHash())
+	return connect.NewResponse(configResponse(conf)), nil
+}
+
+func (s *Server) UnpinConfig(
+	ctx context.Context,
+	req *connect.Request[serverv1.ConfigSourceRequest],
+) (*connect.Response[serverv1.GetConfigResponse], error) {
+	s.mappingsMu.Lock()
+	defer s.mappingsMu.Unlock()
+	conf := s.configs.Get(ctx, store.Hash([]byte(req.Msg.GetSource())))
+	if conf == nil {
+		return nil, connect.NewError(connect.CodeNotFound, ErrConfigNotFound)
+	}
+	if conf.Unpin() {
+		if err := s.persistConfig(ctx, conf); err != nil {
+			return nil, connect.NewError(connect.CodeInternal, err)
+		}
+		slog.Info("Unpinned config", "source", conf.Source())
+	}
+	return connect.NewResponse(configResponse(conf)), nil
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"testing"
+
+	"connectrpc.com/connect"
+	serverv1 "github.com/myLogic207/go-arcs/api/gen/proto/go/server/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPinConfig(t *testing.T) {
+	dataDir := t.TempDir()
+	f := newFixture(t, newDiskConfigs(t, dataDir))
+	attributes := map[string]string{"env": "prod"}
+	conf := f.mapping("conf.alloy", "// v1", attributes)
+	f.load(conf)
+	f.register("collector", attributes)
+	source := &serverv1.ConfigSourceRequest{Source: conf.Source()}
+
+	assert.Equal(t, "// v1", f.content("collector"))
+	f.write("conf.alloy", "// v2")
+	assert.Equal(t, "// v2", f.content("collector"))
+	versions, err := f.ListConfigVersions(f.ctx, connect.NewRequest(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(t, versions.Msg.GetVersions(), 2) {
+		return
+	}
+	first := versions.Msg.GetVersions()[1]
+	assert.Equal(t, "// v1", first.GetContent())
+
+	res, err := f.PinConfig(f.ctx, connect.NewRequest(&serverv1.PinConfigRequest{
+		Source: conf.Source(),
+		Hash:   first.GetHash(),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, first.GetHash(), res.Msg.GetPinnedHash())
+	f.write("conf.alloy", "// v3")
+	assert.Equal(t, "// v1", f.content("collector"))
+	// the pin is logged at once, not only when the store is compacted
+	if pinned, ok := restoreConfigs(t, dataDir).Get(f.ctx, conf.ID()).Pinned(); assert.True(t, ok) {
+		assert.Equal(t, first.GetHash(), pinned.Hash)
+	}
+
+	tests := []struct {
+		name string
+		req  *serverv1.PinConfigRequest
+	}{
+		{name: "unknown hash", req: &serverv1.PinConfigRequest{Source: conf.Source(), Hash: "unknown"}},
+		{name: "unknown source", req: &serverv1.PinConfigRequest{Source: "file://unknown.alloy", Hash: first.GetHash()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.PinConfig(f.ctx, connect.NewRequest(tt.req))
+			assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+		})
+	}
+
+	res, err = f.UnpinConfig(f.ctx, connect.NewRequest(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, res.Msg.GetPinnedHash())
+	assert.Equal(t, "// v3", f.content("collector"))
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+type loggingInterceptor struct{}
+
+// logs every handled RPC with its procedure, peer, collector, status code and duration
+func newLoggingInterceptor() connect.Interceptor {
+	return loggingInterceptor{}
+}
+
+func logRequest(ctx context.Context, spec connect.Spec, peer connect.Peer, collectorID string, start time.Time, err error) {
+	level := slog.LevelInfo
+	code := "ok"
+	if err != nil {
+		code = connect.CodeOf(err).String()
+		level = slog.LevelWarn
+		switch connect.CodeOf(err) {
+		case connect.CodeInternal, connect.CodeUnknown, connect.CodeDataLoss:
+			level = slog.LevelError
+		}
+	}
+	attrs := []slog.Attr{
+		slog.String("procedure", spec.Procedure),
+		slog.String("peer", peer.Addr),
+		slog.String("code", code),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if collectorID != "" {
+		attrs = append(attrs, slog.String("collector", collectorID))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	slog.LogAttrs(ctx, level, "Handled request", attrs...)
+}
+
+func (loggingInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		start := time.Now()
+		res, err := next(ctx, req)
+		collectorID := ""
+		if msg, ok := req.Any().(interface{ GetId() string }); ok {
+			collectorID = msg.GetId()
+		}
+		logRequest(ctx, req.Spec(), req.Peer(), collectorID, start, err)
+		return res, err
+	}
+}
+
+func (loggingInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (loggingInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		start := time.Now()
+		err := next(ctx, conn)
+		logRequest(ctx, conn.Spec(), conn.Peer(), "", start, err)
+		return err
+	}
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"connectrpc.com/connect"
+	collectorv1 "github.com/grafana/alloy-remote-config/api/gen/proto/go/collector/v1"
+	"github.com/grafana/alloy-remote-config/api/gen/proto/go/collector/v1/collectorv1connect"
+	"github.com/stretchr/testify/assert"
+)
+
+// collects the records of the default logger
+type logRecorder struct {
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+func (r *logRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.buf.Write(p)
+}
+
+func (r *logRecorder) records(t *testing.T, msg string) []map[string]any {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var records []map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(r.buf.Bytes()))
+	for decoder.More() {
+		var record map[string]any
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatal(err)
+		}
+		if record[slog.MessageKey] == msg {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+func recordLogs(t *testing.T) *logRecorder {
+	recorder := &logRecorder{}
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(recorder, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return recorder
+}
+
+func TestLoggingInterceptor(t *testing.T) {
+	ctx := context.Background()
+	recorder := recordLogs(t)
+	server := serveWithCertificates(New("", nil, nil))
+	defer server.Close()
+	client := collectorv1connect.NewCollectorServiceClient(server.Client(), server.URL)
+
+	if _, err := client.RegisterCollector(ctx, connect.NewRequest(&collectorv1.RegisterCollectorRequest{Id: "collector"})); err != nil {
+		t.Fatal(err)
+	}
+	_, err := client.GetConfig(ctx, connect.NewRequest(&collectorv1.GetConfigRequest{Id: "unknown"}))
+	assert.Error(t, err)
+
+	records := recorder.records(t, "Handled request")
+	if !assert.Len(t, records, 2) {
+		return
+	}
+	tests := []struct {
+		procedure string
+		code      string
+		level     string
+		collector string
+		err       bool
+	}{
+		{procedure: collectorv1connect.CollectorServiceRegisterCollectorProcedure, code: "ok", level: "INFO", collector: "collector"},
+		{procedure: collectorv1connect.CollectorServiceGetConfigProcedure, code: connect.CodeNotFound.String(), level: "WARN", collector: "unknown", err: true},
+	}
+	for i, tt := range tests {
+		record := records[i]
+		assert.Equal(t, tt.procedure, record["procedure"])
+		assert.Equal(t, tt.code, record["code"])
+		assert.Equal(t, tt.level, record[slog.LevelKey])
+		assert.Equal(t, tt.collector, record["collector"])
+		assert.NotEmpty(t, record["peer"])
+		// durations are encoded in nanoseconds
+		assert.IsType(t, float64(0), record["duration"])
+		if tt.err {
+			assert.NotEmpty(t, record["error"])
+		} else {
+			assert.NotContains(t, record, "error")
+		}
+	}
+}
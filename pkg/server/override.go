@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"time"
+
+	"connectrpc.com/connect"
+	serverv1 "github.com/myLogic207/go-arcs/api/gen/proto/go/server/v1"
+	"github.com/myLogic207/go-arcs/pkg/mappings/collector"
+	"github.com/myLogic207/go-arcs/pkg/mappings/config"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	ErrOverrideInvalid = errors.New("invalid collector override")
+	ErrOverrideStore   = errors.New("could not store collector override")
+)
+
+func (s *Server) SetCollectorOverride(
+	ctx context.Context,
+	req *connect.Request[serverv1.SetCollectorOverrideRequest],
+) (*connect.Response[serverv1.GetCollectorsResponse], error) {
+	id := req.Msg.GetId()
+	col := s.collectors.Get(ctx, id)
+	if col == nil {
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("%w: %v", ErrCollectorNotRegistered, id))
+	}
+	mode, err := collector.ParseOverrideMode(req.Msg.GetMode())
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.Join(ErrOverrideInvalid, err))
+	}
+	override := collector.Override{Source: req.Msg.GetSource(), Mode: mode}
+	if ttl := req.Msg.GetTtl(); ttl != "" {
+		duration, err := time.ParseDuration(ttl)
+		if err != nil || duration <= 0 {
+			return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("%w: ttl %q", ErrOverrideInvalid, ttl))
+		}
+		override.Expires = time.Now().Add(duration)
+	}
+	conf, err := newOverrideConfig(override.Source)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.Join(ErrOverrideInvalid, err))
+	}
+
+	previous, hadOverride := col.Override()
+	col.SetOverride(&override)
+	if _, err := s.collectors.Set(ctx, col); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, errors.Join(ErrOverrideStore, err))
+	}
+	s.overridesMu.Lock()
+	if _, ok := s.overrides[override.Source]; !ok {
+		s.overrides[override.Source] = conf
+	}
+	s.overridesMu.Unlock()
+	if hadOverride && previous.Source != override.Source {
+		s.releaseOverride(ctx, previous.Source)
+	}
+	slog.Warn("Attached override to collector", "collector", id, "source", override.Source, "mode", mode, "expires", override.Expires)
+	return connect.NewResponse(s.collectorResponse(col)), nil
+}
+
+func (s *Server) ClearCollectorOverride(
+	ctx context.Context,
+	req *connect.Request[serverv1.GetCollectorRequest],
+) (*connect.Response[serverv1.GetCollectorsResponse], error) {
+	id := req.Msg.GetId()
+	col := s.collectors.Get(ctx, id)
+	if col == nil {
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("%w: %v", ErrCollectorNotRegistered, id))
+	}
+	if err := s.clearOverride(ctx, col); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	return connect.NewResponse(s.collectorResponse(col)), nil
+}
+
+// removes the override of a collector, if any
+func (s *Server) clearOverride(ctx context.Context, col collector.Collector) error {
+	override, ok := col.Override()
+	if !ok {
+		return nil
+	}
+	col.SetOverride(nil)
+	if _, err := s.collectors.Set(ctx, col); err != nil {
+		return errors.Join(ErrOverrideStore, err)
+	}
+	s.releaseOverride(ctx, override.Source)
+	slog.Info("Removed override of collector", "collector", col.ID(), "source", override.Source)
+	return nil
+}
+
+// drops the config of an override source once no collector uses it anymore
+func (s *Server) releaseOverride(ctx context.Context, source string) {
+	for _, col := range s.collectors.List(ctx) {
+		if override, ok := col.Override(); ok && override.Source == source {
+			return
+		}
+	}
+	s.overridesMu.Lock()
+	delete(s.overrides, source)
+	s.overridesMu.Unlock()
+}
+
+// applies the override of a collector to the configs matching it,
+// expired overrides are removed
+func (s *Server) overrideConfigs(
+	ctx context.Context,
+	col collector.Collector,
+	configs []config.Config,
+) ([]config.Config, error) {
+	override, ok := col.Override()
+	if !ok {
+		return configs, nil
+	}
+	if override.Expired(time.Now()) {
+		slog.Info("Override of collector expired", "collector", col.ID(), "source", override.Source)
+		if err := s.clearOverride(ctx, col); err != nil {
+			slog.Error("Failed to remove expired override", "collector", col.ID(), "error", err)
+		}
+		return configs, nil
+	}
+
+	s.overridesMu.Lock()
+	conf, ok := s.overrides[override.Source]
+	if !ok {
+		// overrides restored from a persistent store
+		var err error
+		if conf, err = newOverrideConfig(override.Source); err != nil {
+			s.overridesMu.Unlock()
+			return nil, errors.Join(ErrOverrideInvalid, err)
+		}
+		s.overrides[override.Source] = conf
+	}
+	s.overridesMu.Unlock()
+
+	if override.Mode == collector.OverrideReplace {
+		return []config.Config{conf}, nil
+	}
+	// a matching config of the same source is delivered once, as override
+	configs = slices.DeleteFunc(slices.Clone(configs), func(c config.Config) bool {
+		return c.Source() == override.Source
+	})
+	return append(configs, conf), nil
+}
+
+// appended overrides compose after all matching configs
+func newOverrideConfig(source string) (config.Config, error) {
+	return config.New(source, nil, config.WithPriority(math.MaxInt))
+}
+
+func overrideResponse(col collector.Collector) *serverv1.CollectorOverride {
+	override, ok := col.Override()
+	if !ok {
+		return nil
+	}
+	res := &serverv1.CollectorOverride{
+		Source: override.Source,
+		Mode:   override.Mode.String(),
+	}
+	if !override.Expires.IsZero() {
+		res.Expires = timestamppb.New(override.Expires)
+	}
+	return res
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	serverv1 "github.com/myLogic207/go-arcs/api/gen/proto/go/server/v1"
+	"github.com/myLogic207/go-arcs/pkg/mappings/collector"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectorOverride(t *testing.T) {
+	f := newFixture(t, nil)
+	attributes := map[string]string{"env": "prod"}
+	fleet := f.mapping("fleet.alloy", "// fleet", attributes)
+	test := f.write("test.alloy", "// test")
+	f.load(fleet)
+	f.register("canary", attributes)
+	f.register("other", attributes)
+
+	steps := []struct {
+		name        string
+		req         *serverv1.SetCollectorOverrideRequest
+		wantMode    string
+		wantExpires bool
+		want        string
+	}{
+		{
+			name:     "replace",
+			req:      &serverv1.SetCollectorOverrideRequest{Id: "canary", Source: test},
+			wantMode: "replace",
+			want:     "// test",
+		},
+		{
+			name:        "append with ttl",
+			req:         &serverv1.SetCollectorOverrideRequest{Id: "canary", Source: test, Mode: "append", Ttl: "1h"},
+			wantMode:    "append",
+			wantExpires: true,
+			want:        "// fleet\n\n// test",
+		},
+		{
+			// a matching source is delivered once
+			name:     "append matching source",
+			req:      &serverv1.SetCollectorOverrideRequest{Id: "canary", Source: fleet.Source(), Mode: "append"},
+			wantMode: "append",
+			want:     "// fleet",
+		},
+	}
+	for _, tt := range steps {
+		res, err := f.SetCollectorOverride(f.ctx, connect.NewRequest(tt.req))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, tt.wantMode, res.Msg.GetOverride().GetMode(), tt.name)
+		assert.Equal(t, tt.wantExpires, res.Msg.GetOverride().GetExpires() != nil, tt.name)
+		assert.Equal(t, tt.want, f.content("canary"), tt.name)
+		assert.Equal(t, "// fleet", f.content("other"), "other collectors keep the matching configs")
+	}
+
+	cleared, err := f.ClearCollectorOverride(f.ctx, connect.NewRequest(&serverv1.GetCollectorRequest{Id: "canary"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, cleared.Msg.GetOverride())
+	assert.Equal(t, "// fleet", f.content("canary"))
+	assert.Empty(t, f.overrides, "unused override configs are released")
+
+	// expired overrides are removed on the next poll
+	col := f.collectors.Get(f.ctx, "canary")
+	col.SetOverride(&collector.Override{Source: test, Expires: time.Now().Add(-time.Second)})
+	assert.Equal(t, "// fleet", f.content("canary"))
+	_, ok := col.Override()
+	assert.False(t, ok)
+
+	tests := []struct {
+		name     string
+		req      *serverv1.SetCollectorOverrideRequest
+		wantCode connect.Code
+	}{
+		{name: "unknown collector", req: &serverv1.SetCollectorOverrideRequest{Id: "unknown", Source: test}, wantCode: connect.CodeNotFound},
+		{name: "invalid source", req: &serverv1.SetCollectorOverrideRequest{Id: "canary", Source: "test.alloy"}, wantCode: connect.CodeInvalidArgument},
+		{name: "invalid mode", req: &serverv1.SetCollectorOverrideRequest{Id: "canary", Source: test, Mode: "merge"}, wantCode: connect.CodeInvalidArgument},
+		{name: "invalid ttl", req: &serverv1.SetCollectorOverrideRequest{Id: "canary", Source: test, Ttl: "-1h"}, wantCode: connect.CodeInvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.SetCollectorOverride(f.ctx, connect.NewRequest(tt.req))
+			assert.Equal(t, tt.wantCode, connect.CodeOf(err))
+		})
+	}
+}
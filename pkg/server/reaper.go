@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/myLogic207/go-arcs/pkg/mappings/collector"
+	"github.com/myLogic207/go-arcs/pkg/metrics"
+)
+
+// bounds of the interval the reaper checks collectors in
+const (
+	minReapInterval = time.Second
+	maxReapInterval = time.Minute
+)
+
+var ErrCollectorReap = errors.New("could not reap collector")
+
+// WithReaper removes collectors not seen for ttl, polling collectors are registered again
+func WithReaper(ttl time.Duration) Option {
+	return func(s *Server) {
+		s.reapTTL = ttl
+	}
+}
+
+// RunReaper removes stale collectors until ctx is done, it returns at once if no ttl is set
+func (s *Server) RunReaper(ctx context.Context) {
+	if s.reapTTL <= 0 {
+		return
+	}
+	interval := min(max(s.reapTTL/4, minReapInterval), maxReapInterval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.reap(ctx, now)
+		}
+	}
+}
+
+// removes collectors not seen since ttl before now, they are remembered for another ttl
+// to register them again under their name when they poll
+func (s *Server) reap(ctx context.Context, now time.Time) []string {
+	var reaped []string
+	for _, col := range s.collectors.List(ctx) {
+		if !s.reapable(col, now) {
+			continue
+		}
+		// the collector might have polled since it was listed
+		col = s.collectors.Get(ctx, col.ID())
+		if col == nil || !s.reapable(col, now) {
+			continue
+		}
+		if _, err := s.collectors.Remove(ctx, col.ID()); err != nil {
+			slog.Error("Failed to reap collector", "collector", col.ID(), "error", errors.Join(ErrCollectorReap, err))
+			continue
+		}
+		s.reapedMu.Lock()
+		s.reaped[col.ID()] = reapedCollector{col, now}
+		s.reapedMu.Unlock()
+		metrics.ObserveReap()
+		slog.Info("Reaped collector", "collector", col.ID(), "name", col.Name(), "last_seen", col.Status().LastSeen)
+		reaped = append(reaped, col.ID())
+	}
+
+	s.reapedMu.Lock()
+	for id, tombstone := range s.reaped {
+		if now.Sub(tombstone.at) >= s.reapTTL {
+			delete(s.reaped, id)
+		}
+	}
+	s.reapedMu.Unlock()
+	return reaped
+}
+
+// collectors not seen since ttl before now are reaped
+func (s *Server) reapable(col collector.Collector, now time.Time) bool {
+	lastSeen := col.Status().LastSeen
+	if lastSeen.IsZero() {
+		// collectors restored without contact count from the start of the server
+		lastSeen = s.started
+	}
+	return now.Sub(lastSeen) >= s.reapTTL
+}
+
+type reapedCollector struct {
+	collector.Collector
+	at time.Time
+}
+
+// registers an unknown collector polling its config again, if reaping is enabled.
+// Collectors reaped within ttl get their name and override back, all others are
+// registered with an empty name.
+func (s *Server) reregister(ctx context.Context, id string, attributes map[string]string, peer string) (collector.Collector, error) {
+	if s.reapTTL <= 0 {
+		return nil, connect.NewError(connect.CodeNotFound, ErrCollectorNotRegistered)
+	}
+	if err := checkIdentity(ctx, id); err != nil {
+		return nil, err
+	}
+	credential, err := s.policy.Credential(ctx, id)
+	if err != nil {
+		return nil, credentialError(err)
+	}
+
+	col := collector.New(id, "", attributes, "")
+	s.reapedMu.Lock()
+	tombstone, ok := s.reaped[id]
+	if ok && tombstone.Credential() != "" && tombstone.Credential() != credential {
+		// keep the tombstone for the collector it belongs to
+		s.reapedMu.Unlock()
+		return nil, credentialError(ErrCredentialMismatch)
+	}
+	delete(s.reaped, id)
+	s.reapedMu.Unlock()
+	if ok {
+		col = collector.New(id, tombstone.Name(), attributes, "")
+		if override, ok := tombstone.Override(); ok {
+			col.SetOverride(&override)
+		}
+	}
+
+	col.SetCredential(credential)
+	col.Seen(peer, nil)
+	if _, err := s.collectors.Set(ctx, col); err != nil {
+		return nil, errors.Join(ErrCollectorAdd, err)
+	}
+	slog.Info("Registered collector again on poll", "collector", id, "name", col.Name())
+	return col, nil
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	collectorv1 "github.com/grafana/alloy-remote-config/api/gen/proto/go/collector/v1"
+	"github.com/grafana/alloy-remote-config/api/gen/proto/go/collector/v1/collectorv1connect"
+	"github.com/myLogic207/go-arcs/pkg/auth"
+	"github.com/myLogic207/go-arcs/pkg/mappings/collector"
+	"github.com/myLogic207/go-arcs/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReap(t *testing.T) {
+	ctx := context.Background()
+	s := New("", nil, nil, WithReaper(time.Minute))
+	_, err := s.RegisterCollector(ctx, connect.NewRequest(&collectorv1.RegisterCollectorRequest{
+		Id:   "alive",
+		Name: "alive-name",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// restored from disk, last seen an hour ago
+	crashed, err := collector.Codec{}.Unmarshal([]byte(fmt.Sprintf(
+		`{"id":"crashed","name":"crashed-name","last_seen":%q}`,
+		time.Now().Add(-time.Hour).Format(time.RFC3339),
+	)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.collectors.Set(ctx, crashed); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{"crashed"}, s.reap(ctx, time.Now()))
+	assert.Nil(t, s.collectors.Get(ctx, "crashed"))
+	assert.NotNil(t, s.collectors.Get(ctx, "alive"))
+
+	// polling registers the collector again under its name
+	_, err = s.GetConfig(ctx, connect.NewRequest(&collectorv1.GetConfigRequest{
+		Id:              "crashed",
+		LocalAttributes: map[string]string{"env": "prod"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	col := s.collectors.Get(ctx, "crashed")
+	if assert.NotNil(t, col) {
+		assert.Equal(t, "crashed-name", col.Name())
+		assert.Equal(t, map[string]string{"env": "prod"}, col.Attributes())
+	}
+
+	// collectors not polling anymore are reaped eventually
+	assert.ElementsMatch(t, []string{"alive", "crashed"}, s.reap(ctx, time.Now().Add(time.Minute)))
+}
+
+func TestReapDisabled(t *testing.T) {
+	ctx := context.Background()
+	s := New("", nil, nil)
+	_, err := s.GetConfig(ctx, connect.NewRequest(&collectorv1.GetConfigRequest{Id: "unknown"}))
+	assert.ErrorIs(t, err, ErrCollectorNotRegistered)
+}
+
+func TestReapIdentity(t *testing.T) {
+	ctx := context.Background()
+	s := New("", nil, nil, WithReaper(time.Minute), WithPolicy(auth.PolicyMTLS))
+	server := serveWithCertificates(s)
+	defer server.Close()
+	client := collectorv1connect.NewCollectorServiceClient(server.Client(), server.URL)
+	getConfig := func(id string, names string) error {
+		req := connect.NewRequest(&collectorv1.GetConfigRequest{Id: id})
+		req.Header().Set(testCertificateHeader, names)
+		_, err := client.GetConfig(ctx, req)
+		return err
+	}
+
+	// unknown collectors are only registered under the names of their certificate
+	err := getConfig("other", "collector")
+	assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err))
+	assert.Nil(t, s.collectors.Get(ctx, "other"))
+	assert.NoError(t, getConfig("unnamed", "unnamed"))
+	if col := s.collectors.Get(ctx, "unnamed"); assert.NotNil(t, col) {
+		assert.Empty(t, col.Name(), "collectors without tombstone are registered without name")
+	}
+
+	register := connect.NewRequest(&collectorv1.RegisterCollectorRequest{Id: "collector", Name: "collector-name"})
+	register.Header().Set(testCertificateHeader, "collector,collector.example.com")
+	if _, err := client.RegisterCollector(ctx, register); err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, s.reap(ctx, time.Now().Add(time.Minute)), "collector")
+
+	// another certificate naming the ID does not take over the reaped collector
+	err = getConfig("collector", "collector,other.example.com")
+	assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err))
+	assert.Nil(t, s.collectors.Get(ctx, "collector"))
+
+	assert.NoError(t, getConfig("collector", "collector,collector.example.com"))
+	if col := s.collectors.Get(ctx, "collector"); assert.NotNil(t, col) {
+		assert.Equal(t, "collector-name", col.Name())
+	}
+}
+
+// lists every collector as last seen an hour ago, like a listing taken before they polled
+type outdatedListStore struct {
+	collector.Store
+}
+
+func (s outdatedListStore) List(ctx context.Context) []collector.Collector {
+	var outdated []collector.Collector
+	for _, col := range s.Store.List(ctx) {
+		col, err := collector.Codec{}.Unmarshal([]byte(fmt.Sprintf(
+			`{"id":%q,"last_seen":%q}`,
+			col.ID(),
+			time.Now().Add(-time.Hour).Format(time.RFC3339),
+		)))
+		if err != nil {
+			panic(err)
+		}
+		outdated = append(outdated, col)
+	}
+	return outdated
+}
+
+func TestReapSeenMeanwhile(t *testing.T) {
+	ctx := context.Background()
+	collectors := outdatedListStore{store.NewStore[collector.Collector](nil, nil)}
+	s := New("", nil, collectors, WithReaper(time.Minute))
+	if _, err := s.RegisterCollector(ctx, connect.NewRequest(&collectorv1.RegisterCollectorRequest{Id: "polling"})); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Empty(t, s.reap(ctx, time.Now()))
+	assert.NotNil(t, s.collectors.Get(ctx, "polling"))
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"connectrpc.com/connect"
+	serverv1 "github.com/myLogic207/go-arcs/api/gen/proto/go/server/v1"
+	"github.com/myLogic207/go-arcs/pkg/mappings/collector"
+	"github.com/myLogic207/go-arcs/pkg/mappings/config"
+	"github.com/myLogic207/go-arcs/pkg/store"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// interval rollouts are checked for promotion in
+const rolloutInterval = 10 * time.Second
+
+// RunRollouts promotes rollouts whose canaries polled healthily for the soak period until ctx is done
+func (s *Server) RunRollouts(ctx context.Context) {
+	ticker := time.NewTicker(rolloutInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.promoteRollouts(ctx, now)
+		}
+	}
+}
+
+// reasons due rollouts are held for
+const (
+	holdNoCanaries = "no canaries registered"
+	holdUnhealthy  = "canaries unhealthy"
+)
+
+// promotes due rollouts if at least one canary is registered and every canary
+// polled since the rollout started and is healthy, returns the promoted sources
+func (s *Server) promoteRollouts(ctx context.Context, now time.Time) []string {
+	var promoted []string
+	for _, conf := range s.configs.List(ctx) {
+		status := conf.Rollout()
+		if !status.Active || now.Before(status.Due) {
+			continue
+		}
+		canaries, unhealthy := s.canaries(ctx, conf, status, now)
+		// nothing tested the content without canaries
+		hold := ""
+		switch {
+		case len(canaries) == 0:
+			hold = holdNoCanaries
+		case len(unhealthy) > 0:
+			hold = holdUnhealthy
+		}
+		if hold != "" {
+			if conf.Hold(status.CanaryHash, hold) {
+				slog.Warn("Holding rollout", "source", conf.Source(), "hash", status.CanaryHash, "reason", hold, "unhealthy", unhealthy)
+			}
+			continue
+		}
+		if err := conf.Promote(); err != nil {
+			// rolled back or promoted in the meantime
+			continue
+		}
+		s.persistRollout(ctx, conf)
+		slog.Info("Promoted rollout", "source", conf.Source(), "hash", status.CanaryHash, "canaries", len(canaries))
+		promoted = append(promoted, conf.Source())
+	}
+	return promoted
+}
+
+// converts the rollout policy and status of a config, nil without rollout
+func rolloutResponse(conf config.Config) (*serverv1.Rollout, *serverv1.RolloutStatus) {
+	policy := config.ToMapping(conf).Rollout
+	if policy == nil {
+		return nil, nil
+	}
+	status := conf.Rollout()
+	res := &serverv1.RolloutStatus{
+		Active:         status.Active,
+		StableHash:     status.StableHash,
+		CanaryHash:     status.CanaryHash,
+		RolledBackHash: status.RolledBackHash,
+		Held:           status.Held,
+	}
+	if status.Active {
+		res.Started = timestamppb.New(status.Started)
+		res.Due = timestamppb.New(status.Due)
+	}
+	return &serverv1.Rollout{
+		Percent:    int32(policy.Percent),
+		Collectors: policy.Collectors,
+		Soak:       policy.Soak,
+	}, res
+}
+
+// returns the canaries of a config and those that did not poll healthily since the rollout started
+func (s *Server) canaries(
+	ctx context.Context,
+	conf config.Config,
+	rollout config.RolloutStatus,
+	now time.Time,
+) ([]string, []string) {
+	var canaries, unhealthy []string
+	for _, col := range s.collectors.List(ctx) {
+		if !conf.Canary(col.ID()) || !conf.Matches(col.Attributes()) {
+			continue
+		}
+		canaries = append(canaries, col.ID())
+		status := col.Status()
+		if status.LastSeen.Before(rollout.Started) ||
+			status.LastError != "" ||
+			status.Health(now, s.staleAfter, s.lostAfter) != collector.HealthHealthy {
+			unhealthy = append(unhealthy, col.ID())
+		}
+	}
+	return canaries, unhealthy
+}
+
+func (s *Server) PromoteConfig(
+	ctx context.Context,
+	req *connect.Request[serverv1.ConfigSourceRequest],
+) (*connect.Response[serverv1.GetConfigResponse], error) {
+	return s.endRollout(ctx, req.Msg.GetSource(), config.Config.Promote)
+}
+
+func (s *Server) RollbackConfig(
+	ctx context.Context,
+	req *connect.Request[serverv1.ConfigSourceRequest],
+) (*connect.Response[serverv1.GetConfigResponse], error) {
+	return s.endRollout(ctx, req.Msg.GetSource(), config.Config.Rollback)
+}
+
+func (s *Server) endRollout(
+	ctx context.Context,
+	source string,
+	end func(config.Config) error,
+) (*connect.Response[serverv1.GetConfigResponse], error) {
+	s.mappingsMu.Lock()
+	defer s.mappingsMu.Unlock()
+	conf := s.configs.Get(ctx, store.Hash([]byte(source)))
+	if conf == nil {
+		return nil, connect.NewError(connect.CodeNotFound, ErrConfigNotFound)
+	}
+	if err := end(conf); err != nil {
+		if errors.Is(err, config.ErrNoRollout) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, err)
+		}
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	if err := s.persistConfig(ctx, conf); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	return connect.NewResponse(configResponse(conf)), nil
+}
+
+// persists the rollout state of a config changed outside of the management services,
+// failures are logged, the state is written again with the next change or compaction
+func (s *Server) persistRollout(ctx context.Context, conf config.Config) {
+	s.mappingsMu.Lock()
+	defer s.mappingsMu.Unlock()
+	if err := s.persistConfig(ctx, conf); err != nil {
+		slog.Error("Failed to persist rollout", "source", conf.Source(), "error", err)
+	}
+}
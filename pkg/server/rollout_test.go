@@ -0,0 +1,134 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	serverv1 "github.com/myLogic207/go-arcs/api/gen/proto/go/server/v1"
+	"github.com/myLogic207/go-arcs/pkg/mappings/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollout(t *testing.T) {
+	f := newFixture(t, nil)
+	attributes := map[string]string{"env": "prod"}
+	conf := f.mapping("conf.alloy", "// v1", attributes, config.WithRollout(&config.RolloutPolicy{
+		Collectors: []string{"canary"},
+		Soak:       "1m",
+	}))
+	f.load(conf)
+	f.register("canary", attributes)
+	f.register("other", attributes)
+	assert.Equal(t, "// v1", f.content("canary"))
+	assert.Equal(t, "// v1", f.content("other"))
+
+	f.write("conf.alloy", "// v2")
+	assert.Equal(t, "// v1", f.content("other"))
+	status := conf.Rollout()
+	// not due yet
+	assert.Empty(t, f.promoteRollouts(f.ctx, time.Now()))
+	// the canary did not poll since
+	assert.Empty(t, f.promoteRollouts(f.ctx, status.Due))
+
+	assert.Equal(t, "// v2", f.content("canary"))
+	assert.Equal(t, []string{conf.Source()}, f.promoteRollouts(f.ctx, status.Due))
+	assert.Equal(t, "// v2", f.content("other"))
+
+	f.write("conf.alloy", "// v3")
+	assert.Equal(t, "// v3", f.content("canary"))
+	source := &serverv1.ConfigSourceRequest{Source: conf.Source()}
+	res, err := f.RollbackConfig(f.ctx, connect.NewRequest(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, res.Msg.GetRolloutStatus().GetActive())
+	assert.NotEmpty(t, res.Msg.GetRolloutStatus().GetRolledBackHash())
+	assert.Equal(t, "// v2", f.content("canary"))
+
+	_, err = f.PromoteConfig(f.ctx, connect.NewRequest(source))
+	assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err))
+}
+
+func TestRolloutWithoutCanaries(t *testing.T) {
+	f := newFixture(t, nil)
+	attributes := map[string]string{"env": "prod"}
+	conf := f.mapping("conf.alloy", "// v1", attributes, config.WithRollout(&config.RolloutPolicy{Collectors: []string{"canary"}}))
+	f.load(conf)
+	f.register("other", attributes)
+	assert.Equal(t, "// v1", f.content("other"))
+	f.write("conf.alloy", "// v2")
+	assert.Equal(t, "// v1", f.content("other"))
+
+	// untested content is not promoted
+	status := conf.Rollout()
+	assert.Empty(t, f.promoteRollouts(f.ctx, status.Due))
+	assert.Equal(t, "// v1", f.content("other"))
+	assert.Equal(t, holdNoCanaries, configResponse(conf).GetRolloutStatus().GetHeld())
+
+	f.register("canary", attributes)
+	assert.Equal(t, "// v2", f.content("canary"))
+	assert.Equal(t, []string{conf.Source()}, f.promoteRollouts(f.ctx, status.Due))
+	assert.Equal(t, "// v2", f.content("other"))
+	assert.Empty(t, configResponse(conf).GetRolloutStatus().GetHeld())
+}
+
+func TestRolloutPersisted(t *testing.T) {
+	dataDir := t.TempDir()
+	f := newFixture(t, newDiskConfigs(t, dataDir))
+	attributes := map[string]string{"env": "prod"}
+	conf := f.mapping("conf.alloy", "// v1", attributes, config.WithRollout(&config.RolloutPolicy{Collectors: []string{"canary"}}))
+	f.load(conf)
+	f.register("canary", attributes)
+	f.content("canary")
+	source := &serverv1.ConfigSourceRequest{Source: conf.Source()}
+
+	// every transition is logged at once, not only when the store is compacted
+	tests := []struct {
+		name       string
+		transition func()
+		wantActive bool
+	}{
+		{
+			name: "start",
+			transition: func() {
+				f.write("conf.alloy", "// v2")
+				f.content("canary")
+			},
+			wantActive: true,
+		},
+		{
+			name: "promote",
+			transition: func() {
+				if _, err := f.PromoteConfig(f.ctx, connect.NewRequest(source)); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "next start",
+			transition: func() {
+				f.write("conf.alloy", "// v3")
+				f.content("canary")
+			},
+			wantActive: true,
+		},
+		{
+			name: "rollback",
+			transition: func() {
+				if _, err := f.RollbackConfig(f.ctx, connect.NewRequest(source)); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt.transition()
+		want := conf.Rollout()
+		restored := restoreConfigs(t, dataDir).Get(f.ctx, conf.ID()).Rollout()
+		assert.Equal(t, tt.wantActive, restored.Active, tt.name)
+		assert.Equal(t, want.StableHash, restored.StableHash, tt.name)
+		assert.Equal(t, want.CanaryHash, restored.CanaryHash, tt.name)
+		assert.Equal(t, want.RolledBackHash, restored.RolledBackHash, tt.name)
+	}
+}
@@ -1,14 +1,21 @@
 package server
 
 import (
-	"log"
+	"crypto/tls"
+	"net"
 	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"connectrpc.com/connect"
+	"connectrpc.com/grpchealth"
 	"github.com/grafana/alloy-remote-config/api/gen/proto/go/collector/v1/collectorv1connect"
 	"github.com/myLogic207/go-arcs/api/gen/proto/go/server/v1/serverv1connect"
+	"github.com/myLogic207/go-arcs/pkg/auth"
 	"github.com/myLogic207/go-arcs/pkg/mappings/collector"
 	"github.com/myLogic207/go-arcs/pkg/mappings/config"
+	"github.com/myLogic207/go-arcs/pkg/metrics"
 	"github.com/myLogic207/go-arcs/pkg/store"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
@@ -18,9 +25,71 @@ type Server struct {
 	*http.Server
 	configs    config.Store
 	collectors collector.Store
+	tokens     *auth.Tokens
+	tls        *tls.Config
+	policy     auth.Policy
+	staleAfter time.Duration
+	lostAfter  time.Duration
+	started    time.Time
+	reapTTL    time.Duration
+	reaped     map[string]reapedCollector
+	reapedMu   sync.Mutex
+	checks     []readinessCheck
+	ready      atomic.Bool
+	// serializes adding, updating and removing configs, shared with reloads of the mappings
+	mappingsMu sync.Locker
+	// configs of collector override sources
+	overrides   map[string]config.Config
+	overridesMu sync.Mutex
 }
 
-func New(addr string, configs config.Store, collectors collector.Store) *Server {
+const (
+	DefaultStaleAfter = 3 * time.Minute
+	DefaultLostAfter  = 10 * time.Minute
+)
+
+// Option sets optional behaviour of the server
+type Option func(*Server)
+
+// WithTokens requires a bearer token with a matching scope for the management services
+func WithTokens(tokens *auth.Tokens) Option {
+	return func(s *Server) {
+		s.tokens = tokens
+	}
+}
+
+// WithTLS serves with TLS, verified client certificates identify collectors
+func WithTLS(config *tls.Config) Option {
+	return func(s *Server) {
+		s.tls = config
+	}
+}
+
+// WithPolicy sets what collectors are bound to on registration,
+// the token policy requires tokens with the enroll scope for the collector service
+func WithPolicy(policy auth.Policy) Option {
+	return func(s *Server) {
+		s.policy = policy
+	}
+}
+
+// WithMappingsLock shares the lock held while changing configs with other writers,
+// e.g. a config.Reloader, so they do not overwrite each other
+func WithMappingsLock(mu sync.Locker) Option {
+	return func(s *Server) {
+		s.mappingsMu = mu
+	}
+}
+
+// WithHealthThresholds sets after how long without contact a collector is stale or lost
+func WithHealthThresholds(staleAfter time.Duration, lostAfter time.Duration) Option {
+	return func(s *Server) {
+		s.staleAfter = staleAfter
+		s.lostAfter = lostAfter
+	}
+}
+
+func New(addr string, configs config.Store, collectors collector.Store, options ...Option) *Server {
 	if configs == nil {
 		configs = store.NewStore[config.Config](nil, nil)
 	}
@@ -30,31 +99,57 @@ func New(addr string, configs config.Store, collectors collector.Store) *Server
 	}
 
 	server := &Server{
-		nil,
-		configs,
-		collectors,
+		configs:    configs,
+		collectors: collectors,
+		staleAfter: DefaultStaleAfter,
+		lostAfter:  DefaultLostAfter,
+		started:    time.Now(),
+		reaped:     make(map[string]reapedCollector),
+		overrides:  make(map[string]config.Config),
+		mappingsMu: &sync.Mutex{},
+	}
+	for _, option := range options {
+		option(server)
+	}
+
+	// metrics and logs come first to record rejected requests as well
+	managerOptions := []connect.HandlerOption{
+		connect.WithInterceptors(metrics.NewInterceptor(), newLoggingInterceptor()),
+	}
+	collectorOptions := []connect.HandlerOption{
+		connect.WithInterceptors(metrics.NewInterceptor(), newLoggingInterceptor()),
+	}
+	if server.tokens != nil {
+		managerOptions = append(managerOptions, connect.WithInterceptors(auth.NewInterceptor(server.tokens)))
+		if server.policy == auth.PolicyToken {
+			collectorOptions = append(collectorOptions, connect.WithInterceptors(auth.NewEnrollInterceptor(server.tokens)))
+		}
 	}
 
 	mux := http.NewServeMux()
-	mux.Handle(collectorv1connect.NewCollectorServiceHandler(server))
-	mux.Handle(serverv1connect.NewCollectorManagerHandler(server))
-	mux.Handle(serverv1connect.NewConfigManagerHandler(server))
+	mux.Handle("/metrics", metrics.Handler())
+	mux.HandleFunc("/healthz", server.handleHealthz)
+	mux.HandleFunc("/readyz", server.handleReadyz)
+	mux.Handle(grpchealth.NewHandler(server))
+	mux.Handle(collectorv1connect.NewCollectorServiceHandler(server, collectorOptions...))
+	mux.Handle(serverv1connect.NewCollectorManagerHandler(server, managerOptions...))
+	mux.Handle(serverv1connect.NewConfigManagerHandler(server, managerOptions...))
 	// Mount some handlers here.
 	server.Server = &http.Server{
-		Addr:    addr,
-		Handler: h2c.NewHandler(mux, &http2.Server{}),
+		Addr:      addr,
+		Handler:   auth.WithPeerIdentity(h2c.NewHandler(mux, &http2.Server{})),
+		TLSConfig: server.tls,
 		// Don't forget timeouts!
 	}
 
 	return server
 }
 
-func logRequest(
-	req connect.AnyRequest,
-) {
-	log.Printf(
-		"Request to %v received from %v",
-		req.Spec().Procedure,
-		req.Peer().Addr,
-	)
+// Serve accepts connections on listener, using TLS if configured
+func (s *Server) Serve(listener net.Listener) error {
+	if s.TLSConfig != nil {
+		// certificates are part of the config
+		return s.Server.ServeTLS(listener, "", "")
+	}
+	return s.Server.Serve(listener)
 }
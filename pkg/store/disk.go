@@ -0,0 +1,279 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	opSet    = "set"
+	opRemove = "remove"
+)
+
+var (
+	ErrStoreOpen    = errors.New("could not open store")
+	ErrStoreWrite   = errors.New("could not write to store log")
+	ErrStoreCompact = errors.New("could not compact store log")
+	ErrStoreClosed  = errors.New("store is closed")
+)
+
+// Codec serializes objects so they can be persisted and restored
+type Codec[t Object] interface {
+	Marshal(t) ([]byte, error)
+	Unmarshal([]byte) (t, error)
+}
+
+// single line in the append-only log
+type logEntry struct {
+	Op   string `json:"op"`
+	ID   string `json:"id"`
+	Data []byte `json:"data,omitempty"`
+}
+
+// diskStore keeps all objects in memory and records every change
+// in an append-only log, which is replayed on start and compacted periodically
+type diskStore[t Object] struct {
+	*store[t]
+	codec Codec[t]
+	path  string
+	// guards the log file and keeps memory and log in the same order
+	fileMu sync.Mutex
+	file   *os.File
+	// closed once the log is compacted and closed
+	done chan struct{}
+}
+
+// Waiter is implemented by stores writing in the background,
+// Wait blocks until they finished after their context is done
+type Waiter interface {
+	Wait()
+}
+
+// Creates a store persisted to dir/name.log, existing entries are restored.
+// The log is compacted every interval and closed once ctx is done.
+func NewDiskStore[t Object](
+	ctx context.Context,
+	dir string,
+	name string,
+	codec Codec[t],
+	interval time.Duration,
+) (Store[t], error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, errors.Join(ErrStoreOpen, err)
+	}
+
+	s := &diskStore[t]{
+		store: NewStore[t](nil, nil).(*store[t]),
+		codec: codec,
+		path:  filepath.Join(dir, name+".log"),
+		done:  make(chan struct{}),
+	}
+	if err := s.replay(ctx); err != nil {
+		return nil, errors.Join(ErrStoreOpen, err)
+	}
+	// rewrite the log right away, drops torn writes and removed objects
+	if err := s.Compact(); err != nil {
+		return nil, err
+	}
+
+	go s.run(ctx, interval)
+	return s, nil
+}
+
+// Wait blocks until the log is compacted and closed after ctx is done
+func (s *diskStore[t]) Wait() {
+	<-s.done
+}
+
+func (s *diskStore[t]) run(ctx context.Context, interval time.Duration) {
+	defer close(s.done)
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			if err := s.Compact(); err != nil {
+				slog.Error("Failed to compact store on close", "path", s.path, "error", err)
+			}
+			s.fileMu.Lock()
+			s.file.Close()
+			s.file = nil
+			s.fileMu.Unlock()
+			return
+		case <-tick:
+			if err := s.Compact(); err != nil {
+				slog.Error("Failed to compact store", "path", s.path, "error", err)
+			}
+		}
+	}
+}
+
+func (s *diskStore[t]) replay(ctx context.Context) error {
+	file, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for line := 1; ; line++ {
+		raw, err := reader.ReadBytes('\n')
+		if err == io.EOF && len(raw) == 0 {
+			return nil
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		var entry logEntry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			// only the last line can be incomplete after a crash
+			slog.Warn("Skipping broken store entry", "path", s.path, "line", line, "error", err)
+			continue
+		}
+		switch entry.Op {
+		case opSet:
+			object, err := s.codec.Unmarshal(entry.Data)
+			if err != nil {
+				slog.Warn("Skipping undecodable store entry", "path", s.path, "line", line, "error", err)
+				continue
+			}
+			if _, err := s.store.Set(ctx, object); err != nil {
+				return err
+			}
+		case opRemove:
+			if _, err := s.store.Remove(ctx, entry.ID); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// must be called while holding fileMu
+func (s *diskStore[t]) write(entry logEntry) error {
+	if s.file == nil {
+		return ErrStoreClosed
+	}
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Join(ErrStoreWrite, err)
+	}
+	if _, err := s.file.Write(append(raw, '\n')); err != nil {
+		return errors.Join(ErrStoreWrite, err)
+	}
+	return nil
+}
+
+// Compact rewrites the log to contain a single entry per stored object
+func (s *diskStore[t]) Compact() error {
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return errors.Join(ErrStoreCompact, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	for _, object := range s.store.List(context.Background()) {
+		data, err := s.codec.Marshal(object)
+		if err != nil {
+			tmp.Close()
+			return errors.Join(ErrStoreCompact, err)
+		}
+		raw, err := json.Marshal(logEntry{Op: opSet, ID: object.ID(), Data: data})
+		if err != nil {
+			tmp.Close()
+			return errors.Join(ErrStoreCompact, err)
+		}
+		writer.Write(append(raw, '\n'))
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return errors.Join(ErrStoreCompact, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.Join(ErrStoreCompact, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Join(ErrStoreCompact, err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return errors.Join(ErrStoreCompact, err)
+	}
+
+	if s.file != nil {
+		s.file.Close()
+	}
+	s.file, err = os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return errors.Join(ErrStoreCompact, err)
+	}
+	return nil
+}
+
+func (s *diskStore[t]) Load(
+	ctx context.Context,
+	objects []t,
+) ([]string, error) {
+	ids := make([]string, len(objects))
+	var errs error
+	for i, object := range objects {
+		id, err := s.Set(ctx, object)
+		ids[i] = id
+		errs = errors.Join(errs, err)
+	}
+	return ids, errs
+}
+
+func (s *diskStore[t]) Set(
+	ctx context.Context,
+	object t,
+) (string, error) {
+	data, err := s.codec.Marshal(object)
+	if err != nil {
+		return "", errors.Join(ErrStoreWrite, err)
+	}
+
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+	if err := s.write(logEntry{Op: opSet, ID: object.ID(), Data: data}); err != nil {
+		return "", err
+	}
+	return s.store.Set(ctx, object)
+}
+
+func (s *diskStore[t]) Remove(
+	ctx context.Context,
+	id string,
+) (bool, error) {
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+	s.store.mu.RLock()
+	_, ok := s.store.objects[id]
+	s.store.mu.RUnlock()
+	if !ok {
+		return false, nil
+	}
+	if err := s.write(logEntry{Op: opRemove, ID: id}); err != nil {
+		return false, err
+	}
+	return s.store.Remove(ctx, id)
+}
@@ -0,0 +1,136 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCodec struct{}
+
+type testRecord struct {
+	ID         string            `json:"id"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+func (testCodec) Marshal(o Object) ([]byte, error) {
+	return json.Marshal(testRecord{ID: o.ID(), Attributes: o.Attributes()})
+}
+
+func (testCodec) Unmarshal(data []byte) (Object, error) {
+	var record testRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	return NewObject(record.ID, record.Attributes), nil
+}
+
+func countLines(t *testing.T, path string) int {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines++
+	}
+	return lines
+}
+
+func TestDiskStoreRestore(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store, err := NewDiskStore[Object](ctx, dir, "test", testCodec{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.Load(ctx, []Object{
+		NewObject("first", map[string]string{"test": "value"}),
+		NewObject("second", map[string]string{"test": "value"}),
+		NewObject("third", map[string]string{"other": "value"}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Remove(ctx, "second"); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := NewDiskStore[Object](ctx, dir, "restored", testCodec{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, restored.List(ctx))
+
+	// reopen the same log
+	if err := os.Rename(filepath.Join(dir, "test.log"), filepath.Join(dir, "copy.log")); err != nil {
+		t.Fatal(err)
+	}
+	restored, err = NewDiskStore[Object](ctx, dir, "copy", testCodec{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, restored.List(ctx), 2)
+	assert.Nil(t, restored.Get(ctx, "second"))
+	assert.Equal(t, map[string]string{"other": "value"}, restored.Get(ctx, "third").Attributes())
+	assert.Len(t, restored.GetByAttributes(ctx, map[string]string{"test": "value"}), 1)
+}
+
+func TestDiskStoreCompact(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store, err := NewDiskStore[Object](ctx, dir, "test", testCodec{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 5 {
+		if _, err := store.Set(ctx, NewObject("id", map[string]string{"test": "value"})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, "test.log")
+	assert.Equal(t, 5, countLines(t, path))
+
+	if err := store.(*diskStore[Object]).Compact(); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, countLines(t, path))
+
+	// ignores a torn write at the end of the log
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"op":"set","id":"br`)
+	file.Close()
+
+	restored, err := NewDiskStore[Object](ctx, dir, "test", testCodec{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, restored.List(ctx), 1)
+}
+
+func TestDiskStoreClosed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	store, err := NewDiskStore[Object](ctx, t.TempDir(), "test", testCodec{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	store.(Waiter).Wait()
+
+	_, err = store.Set(context.Background(), NewObject("id", nil))
+	assert.ErrorIs(t, err, ErrStoreClosed)
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"errors"
+)
+
+var (
+	ErrMatchMode = errors.New("unknown match mode, use 'all' or 'any'")
+)
+
+// MatchMode defines when the attributes of an object apply to requested attributes
+type MatchMode uint8
+
+const (
+	// every attribute of the object has to be present in the request
+	MatchAll MatchMode = iota
+	// a single attribute of the object present in the request is enough
+	MatchAny
+)
+
+// Matcher is implemented by objects that decide themselves
+// if they apply to a set of requested attributes
+type Matcher interface {
+	Matches(map[string]string) bool
+}
+
+func ParseMatchMode(raw string) (MatchMode, error) {
+	switch raw {
+	case "", "all":
+		return MatchAll, nil
+	case "any":
+		return MatchAny, nil
+	default:
+		return MatchAll, ErrMatchMode
+	}
+}
+
+func (m MatchMode) String() string {
+	switch m {
+	case MatchAny:
+		return "any"
+	default:
+		return "all"
+	}
+}
+
+// Match reports whether attributes satisfy the requested attributes using mode,
+// objects without attributes never match
+func Match(mode MatchMode, attributes map[string]string, requested map[string]string) bool {
+	if len(attributes) == 0 {
+		return false
+	}
+	for key, val := range attributes {
+		reqVal, ok := requested[key]
+		matched := ok && reqVal == val
+		if mode == MatchAny && matched {
+			return true
+		}
+		if mode == MatchAll && !matched {
+			return false
+		}
+	}
+	return mode == MatchAll
+}
@@ -0,0 +1,247 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrSelectorSyntax = errors.New("could not parse selector")
+)
+
+type operator string
+
+const (
+	opExists    operator = "exists"
+	opNotExists operator = "!"
+	opEquals    operator = "="
+	opNotEquals operator = "!="
+	opIn        operator = "in"
+	opNotIn     operator = "notin"
+	opRegex     operator = "=~"
+	opNotRegex  operator = "!~"
+	opGreater   operator = ">"
+	opGreaterEq operator = ">="
+	opLess      operator = "<"
+	opLessEq    operator = "<="
+)
+
+var (
+	keyPattern      = `[A-Za-z0-9_.\-/]+`
+	existsPattern   = regexp.MustCompile(`^(!?)\s*(` + keyPattern + `)$`)
+	setPattern      = regexp.MustCompile(`^(` + keyPattern + `)\s+(in|notin)\s*\((.*)\)$`)
+	comparePattern  = regexp.MustCompile(`^(` + keyPattern + `)\s*(==|=~|!=|!~|>=|<=|=|>|<)\s*(.*)$`)
+	closingBrackets = map[rune]rune{')': '(', ']': '[', '}': '{'}
+)
+
+// Selector is a list of kubernetes style label requirements, all have to match.
+// Supported are `key`, `!key`, `key = value`, `key != value`, `key in (a,b)`,
+// `key notin (a,b)`, `key =~ regex`, `key !~ regex` and version comparisons
+// with `>`, `>=`, `<` and `<=`.
+type Selector struct {
+	raw          string
+	requirements []requirement
+}
+
+type requirement struct {
+	key     string
+	op      operator
+	values  []string
+	pattern *regexp.Regexp
+}
+
+// ParseSelector parses a comma separated list of requirements
+func ParseSelector(raw string) (*Selector, error) {
+	selector := &Selector{raw: strings.TrimSpace(raw)}
+	if selector.raw == "" {
+		return selector, nil
+	}
+
+	for _, part := range splitTopLevel(selector.raw) {
+		req, err := parseRequirement(strings.TrimSpace(part))
+		if err != nil {
+			return nil, errors.Join(ErrSelectorSyntax, err)
+		}
+		selector.requirements = append(selector.requirements, req)
+	}
+	return selector, nil
+}
+
+// split on commas that are not enclosed in brackets
+func splitTopLevel(raw string) []string {
+	var parts []string
+	var open []rune
+	start := 0
+	for i, char := range raw {
+		switch char {
+		case '(', '[', '{':
+			open = append(open, char)
+		case ')', ']', '}':
+			if len(open) > 0 && open[len(open)-1] == closingBrackets[char] {
+				open = open[:len(open)-1]
+			}
+		case ',':
+			if len(open) == 0 {
+				parts = append(parts, raw[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, raw[start:])
+}
+
+func parseRequirement(raw string) (requirement, error) {
+	if match := existsPattern.FindStringSubmatch(raw); match != nil {
+		if match[1] == "!" {
+			return requirement{key: match[2], op: opNotExists}, nil
+		}
+		return requirement{key: match[2], op: opExists}, nil
+	}
+
+	if match := setPattern.FindStringSubmatch(raw); match != nil {
+		var values []string
+		for _, value := range strings.Split(match[3], ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+		if len(values) == 0 {
+			return requirement{}, fmt.Errorf("'%v' needs at least one value", raw)
+		}
+		return requirement{key: match[1], op: operator(match[2]), values: values}, nil
+	}
+
+	if match := comparePattern.FindStringSubmatch(raw); match != nil {
+		req := requirement{key: match[1], op: operator(match[2]), values: []string{strings.TrimSpace(match[3])}}
+		switch req.op {
+		case "==":
+			req.op = opEquals
+		case opRegex, opNotRegex:
+			pattern, err := regexp.Compile(req.values[0])
+			if err != nil {
+				return requirement{}, err
+			}
+			req.pattern = pattern
+		case opGreater, opGreaterEq, opLess, opLessEq:
+			if _, ok := parseVersion(req.values[0]); !ok {
+				return requirement{}, fmt.Errorf("'%v' is not a comparable version", req.values[0])
+			}
+		}
+		return req, nil
+	}
+
+	return requirement{}, fmt.Errorf("unknown requirement '%v'", raw)
+}
+
+// Matches reports whether all requirements are satisfied by attributes
+func (s *Selector) Matches(attributes map[string]string) bool {
+	for _, req := range s.requirements {
+		if !req.matches(attributes) {
+			return false
+		}
+	}
+	return true
+}
+
+// Empty reports whether the selector has no requirements
+func (s *Selector) Empty() bool {
+	return s == nil || len(s.requirements) == 0
+}
+
+func (s *Selector) String() string {
+	if s == nil {
+		return ""
+	}
+	return s.raw
+}
+
+func (r requirement) matches(attributes map[string]string) bool {
+	value, ok := attributes[r.key]
+	switch r.op {
+	case opExists:
+		return ok
+	case opNotExists:
+		return !ok
+	case opEquals:
+		return ok && value == r.values[0]
+	case opNotEquals:
+		return !ok || value != r.values[0]
+	case opIn:
+		return ok && slices.Contains(r.values, value)
+	case opNotIn:
+		return !ok || !slices.Contains(r.values, value)
+	case opRegex:
+		return ok && r.pattern.MatchString(value)
+	case opNotRegex:
+		return !ok || !r.pattern.MatchString(value)
+	}
+
+	if !ok {
+		return false
+	}
+	cmp, ok := compareVersions(value, r.values[0])
+	if !ok {
+		return false
+	}
+	switch r.op {
+	case opGreater:
+		return cmp > 0
+	case opGreaterEq:
+		return cmp >= 0
+	case opLess:
+		return cmp < 0
+	case opLessEq:
+		return cmp <= 0
+	}
+	return false
+}
+
+// parses versions like 'v1.4.2' into their numeric segments
+func parseVersion(raw string) ([]int, bool) {
+	raw = strings.TrimPrefix(strings.TrimSpace(raw), "v")
+	if raw == "" {
+		return nil, false
+	}
+	parts := strings.Split(raw, ".")
+	segments := make([]int, len(parts))
+	for i, part := range parts {
+		segment, err := strconv.Atoi(part)
+		if err != nil || segment < 0 {
+			return nil, false
+		}
+		segments[i] = segment
+	}
+	return segments, true
+}
+
+// compares two versions segment by segment, missing segments count as 0
+func compareVersions(a string, b string) (int, bool) {
+	left, ok := parseVersion(a)
+	if !ok {
+		return 0, false
+	}
+	right, ok := parseVersion(b)
+	if !ok {
+		return 0, false
+	}
+	for i := range max(len(left), len(right)) {
+		var l, r int
+		if i < len(left) {
+			l = left[i]
+		}
+		if i < len(right) {
+			r = right[i]
+		}
+		if l != r {
+			if l > r {
+				return 1, true
+			}
+			return -1, true
+		}
+	}
+	return 0, true
+}
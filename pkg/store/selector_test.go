@@ -0,0 +1,59 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelector(t *testing.T) {
+	attributes := map[string]string{
+		"env":     "prod",
+		"region":  "us",
+		"version": "1.10.2",
+		"host":    "web-12",
+	}
+	tests := []struct {
+		name     string
+		selector string
+		want     bool
+		wantErr  bool
+	}{
+		{name: "empty", selector: "", want: true},
+		{name: "equals", selector: "env = prod", want: true},
+		{name: "double equals", selector: "env==prod", want: true},
+		{name: "not equals", selector: "region != eu", want: true},
+		{name: "not equals missing key", selector: "team != a", want: true},
+		{name: "in", selector: "env in (prod,staging)", want: true},
+		{name: "not in", selector: "env notin (prod, staging)", want: false},
+		{name: "exists", selector: "host", want: true},
+		{name: "not exists", selector: "!canary", want: true},
+		{name: "not exists present", selector: "!env", want: false},
+		{name: "version greater equal", selector: "version >= 1.4", want: true},
+		{name: "version less", selector: "version < 1.4", want: false},
+		{name: "version with prefix", selector: "version > v1.10.1", want: true},
+		{name: "regex", selector: "host =~ ^web-[0-9]{1,3}$", want: true},
+		{name: "not regex", selector: "host !~ ^db-", want: true},
+		{name: "combined", selector: "env in (prod,staging), region != eu, !canary, version >= 1.4", want: true},
+		{name: "combined mismatch", selector: "env in (prod,staging), region = eu", want: false},
+		{name: "fail empty set", selector: "env in ()", wantErr: true},
+		{name: "fail regex", selector: "host =~ [", wantErr: true},
+		{name: "fail version", selector: "version > latest", wantErr: true},
+		{name: "fail syntax", selector: "env prod", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := ParseSelector(tt.selector)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseSelector() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			assert.Equal(t, tt.want, selector.Matches(attributes))
+			assert.Equal(t, tt.selector, selector.String())
+		})
+	}
+}
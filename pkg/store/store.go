@@ -24,7 +24,7 @@ type Store[t Object] interface {
 	Remove(context.Context, string) (bool, error)
 	// returns object based on id, nil if non found
 	Get(context.Context, string) t
-	// returns objects based on attribute
+	// returns objects matching the attributes
 	GetByAttributes(context.Context, map[string]string) []t
 	// returns all objects
 	List(context.Context) []t
@@ -80,6 +80,10 @@ func (s *store[t]) Set(
 ) (string, error) {
 	id := object.ID()
 	s.mu.Lock()
+	// drop mappings of a replaced object, its attributes might have changed
+	if existing, ok := s.objects[id]; ok {
+		s.unmap(id, existing)
+	}
 	s.objects[id] = object
 
 	for key, val := range object.Attributes() {
@@ -110,25 +114,30 @@ func (s *store[t]) Remove(
 	_ context.Context,
 	id string,
 ) (bool, error) {
-	s.mu.RLock()
-	if _, ok := s.objects[id]; !ok {
+	// remove existing objects only
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	config, ok := s.objects[id]
+	if !ok {
 		return false, nil
 	}
-	s.mu.RUnlock()
+	s.unmap(id, config)
+	delete(s.objects, id)
 
-	// remove existing objects only
-	s.mu.Lock()
-	config := s.objects[id]
-	for key, val := range config.Attributes() {
+	return true, nil
+}
+
+// removes the attribute mappings of an object, must be called while holding the lock
+func (s *store[t]) unmap(id string, object t) {
+	for key, val := range object.Attributes() {
 		delete(s.mappings[key][val], id)
+		if len(s.mappings[key][val]) == 0 {
+			delete(s.mappings[key], val)
+		}
 		if len(s.mappings[key]) == 0 {
 			delete(s.mappings, key)
 		}
 	}
-	delete(s.objects, id)
-	s.mu.Unlock()
-
-	return true, nil
 }
 
 func (s *store[t]) List(
@@ -143,26 +152,36 @@ func (s *store[t]) List(
 	return objects
 }
 
+// returns objects that have any of the attributes,
+// objects implementing Matcher decide themselves
 func (s *store[t]) GetByAttributes(
 	_ context.Context,
 	attributes map[string]string,
 ) []t {
-	var ids []string
+	ids := make(map[string]bool)
 	s.mu.RLock()
+	defer s.mu.RUnlock()
 	for key, val := range attributes {
 		for id, active := range s.mappings[key][val] {
 			if !active {
 				continue
 			}
-			ids = append(ids, id)
+			ids[id] = true
 		}
 	}
 
-	objects := make([]t, len(ids))
-	for i, id := range ids {
-		objects[i] = s.objects[id]
+	var objects []t
+	for id, object := range s.objects {
+		if matcher, ok := any(object).(Matcher); ok {
+			if matcher.Matches(attributes) {
+				objects = append(objects, object)
+			}
+			continue
+		}
+		if ids[id] {
+			objects = append(objects, object)
+		}
 	}
-	s.mu.RUnlock()
 	return objects
 }
 
@@ -204,3 +204,72 @@ func TestRemoveConfig(t *testing.T) {
 	store.Remove(ctx, storeID)
 	assert.Nil(t, store.Get(ctx, storeID))
 }
+
+func TestMatch(t *testing.T) {
+	attributes := map[string]string{
+		"env":  "prod",
+		"team": "a",
+	}
+	tests := []struct {
+		name      string
+		mode      MatchMode
+		requested map[string]string
+		want      bool
+	}{
+		{
+			name:      "all satisfied",
+			mode:      MatchAll,
+			requested: map[string]string{"env": "prod", "team": "a", "extra": "value"},
+			want:      true,
+		},
+		{
+			name:      "all partially satisfied",
+			mode:      MatchAll,
+			requested: map[string]string{"env": "prod", "team": "b"},
+			want:      false,
+		},
+		{
+			name:      "any partially satisfied",
+			mode:      MatchAny,
+			requested: map[string]string{"env": "prod", "team": "b"},
+			want:      true,
+		},
+		{
+			name:      "any not satisfied",
+			mode:      MatchAny,
+			requested: map[string]string{"env": "dev"},
+			want:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Match(tt.mode, attributes, tt.requested))
+		})
+	}
+}
+
+type matchObject struct {
+	object
+}
+
+func (o *matchObject) Matches(attributes map[string]string) bool {
+	return Match(MatchAll, o.attributes, attributes)
+}
+
+func TestGetByAttributesMatcher(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore[Object](nil, nil)
+	store.Load(ctx, []Object{
+		&matchObject{object{id: "prod-a", attributes: map[string]string{"env": "prod", "team": "a"}}},
+		&matchObject{object{id: "prod-b", attributes: map[string]string{"env": "prod", "team": "b"}}},
+		&matchObject{object{id: "prod", attributes: map[string]string{"env": "prod"}}},
+	})
+
+	objects := store.GetByAttributes(ctx, map[string]string{"env": "prod", "team": "a"})
+	ids := make([]string, len(objects))
+	for i, object := range objects {
+		ids[i] = object.ID()
+	}
+	assert.ElementsMatch(t, []string{"prod-a", "prod"}, ids)
+}